@@ -33,6 +33,20 @@ import (
 	"github.com/Azure/buffalo-azure/generators/eventgrid"
 )
 
+// These constants define a parameter which allows control over whether migrations for the
+// tables used by the Event Grid SDK are generated.
+const (
+	MigrationsName  = "migrations"
+	migrationsUsage = "Add fizz migrations which create the table used by an eventgrid.PopStore."
+)
+
+// These constants define a parameter which allows control over whether the generated subscriber
+// keeps the Events it receives.
+const (
+	CacheName  = "cache"
+	cacheUsage = "Keep each Event received, along with the outcome of handling it, so that recent Events can be listed."
+)
+
 // eventgridCmd represents the eventgrid command
 var eventgridCmd = &cobra.Command{
 	Use:     "eventgrid <name> [<EventTypeString>:<type identifier>...]",
@@ -80,10 +94,12 @@ https://azure.microsoft.com/en-us/services/event-grid/`,
 				return
 			}
 
-			types[eventType], err = eventgrid.NewTypeStubIdentifier(goType)
+			types[eventType], err = resolveType(goType)
 		}
 
-		gen := eventgrid.Generator{}
+		migrations, _ := cmd.Flags().GetBool(MigrationsName)
+		cache, _ := cmd.Flags().GetBool(CacheName)
+		gen := eventgrid.Generator{Migrations: migrations, Cache: cache}
 
 		if err := gen.Run(meta.New("."), name, types); err != nil {
 			fmt.Fprintln(os.Stderr, "unable to create subscriber file: ", err)
@@ -108,6 +124,9 @@ https://azure.microsoft.com/en-us/services/event-grid/`,
 func init() {
 	rootCmd.AddCommand(eventgridCmd)
 
+	eventgridCmd.Flags().Bool(MigrationsName, false, migrationsUsage)
+	eventgridCmd.Flags().Bool(CacheName, false, cacheUsage)
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -120,7 +139,7 @@ func init() {
 }
 
 func parseEventArg(arg string) (string, string, error) {
-	if typeIdentifier, ok := wellKnownEvents[arg]; ok {
+	if typeIdentifier, ok := wellKnownEvent(arg); ok {
 		return arg, typeIdentifier, nil
 	}
 
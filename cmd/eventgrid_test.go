@@ -20,7 +20,7 @@ func TestParseEventArg(t *testing.T) {
 		{
 			"Microsoft.Storage.BlobCreated",
 			"Microsoft.Storage.BlobCreated",
-			wellKnownEvents["Microsoft.Storage.BlobCreated"],
+			"github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.StorageBlobCreatedEventData",
 			nil,
 		},
 		{
@@ -1,22 +1,40 @@
 package cmd
 
-var wellKnownEvents = map[string]string{
-	"Microsoft.ContainerRegistry.ImagePushed":                              "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ContainerRegistryImagePushedEventData",
-	"Microsoft.ContainerRegistry.ImageDeleted":                             "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ContainerRegistryImageDeletedEventData",
-	"Microsoft.EventGrid.SubscriptionValidationEvent":                      "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.SubscriptionValidationEventData",
-	"Microsoft.EventGrid.SubscriptionDeletedEvent":                         "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.SubscriptionDeletedEventData",
-	"Microsoft.EventHub.CaptureFileCreated":                                "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.EventHubCaptureFileCreatedEventData",
-	"Microsoft.Devices.DeviceCreated":                                      "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.IotHubDeviceCreatedEventData",
-	"Microsoft.Devices.DeviceDeleted":                                      "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.IotHubDeviceDeletedEventData",
-	"Microsoft.Media.JobStateChanged":                                      "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.MediaJobStateChangeEventData",
-	"Microsoft.Resources.ResourceDeleteCancel":                             "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ResourceDeleteCancelData",
-	"Microsoft.Resources.ResourceDeleteFailure":                            "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ResourceDeleteFailureData",
-	"Microsoft.Resources.ResourceDeleteSuccess":                            "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ResourceDeleteSuccessData",
-	"Microsoft.Resources.ResourceWriteCancel":                              "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ResourceWriteCancelData",
-	"Microsoft.Resources.ResourceWriteFailure":                             "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ResourceWriteFailureData",
-	"Microsoft.Resources.ResourceWriteSuccess":                             "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ResourceWriteSuccessData",
-	"Microsoft.ServiceBus.ActiveMessagesAvailableWithNoListeners":          "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ServiceBusActiveMessagesAvailableWithNoListenersEventData",
-	"Microsoft.ServiceBus.DeadletterMessagesAvailableWithNoListenersEvent": "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.ServiceBusDeadletterMessagesAvailableWithNoListenersEventData",
-	"Microsoft.Storage.BlobCreated":                                        "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.StorageBlobCreatedEventData",
-	"Microsoft.Storage.BlobDeleted":                                        "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.StorageBlobDeletedEventData",
+import (
+	"reflect"
+
+	"github.com/Azure/buffalo-azure/generators/eventgrid"
+	sdkeg "github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+// wellKnownEvent finds the type that the SDK's default TypeRegistry associates with an Event
+// Type, and describes it as a type identifier of the form "<package path>.<type name>".
+func wellKnownEvent(eventType string) (string, bool) {
+	t, ok := sdkeg.DefaultTypeRegistry.Lookup(eventType, "")
+	if !ok || t.PkgPath() == "" || t.Name() == "" {
+		return "", false
+	}
+	return typeIdentifier(t), true
+}
+
+// typeIdentifier describes a type in the form "<package path>.<type name>".
+func typeIdentifier(t reflect.Type) string {
+	return t.PkgPath() + "." + t.Name()
+}
+
+// resolveType finds the type described by a type identifier. Types registered in the SDK's
+// default TypeRegistry are known in full, which allows the generator to describe them in
+// detail. Only the name of any other type is known.
+func resolveType(identifier string) (reflect.Type, error) {
+	for _, eventType := range sdkeg.DefaultTypeRegistry.EventTypes() {
+		if t, ok := sdkeg.DefaultTypeRegistry.Lookup(eventType, ""); ok && typeIdentifier(t) == identifier {
+			return t, nil
+		}
+	}
+
+	stub, err := eventgrid.NewTypeStubIdentifier(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return stub, nil
 }
@@ -3,18 +3,52 @@ package cmd
 import (
 	"strings"
 	"testing"
+
+	generator "github.com/Azure/buffalo-azure/generators/eventgrid"
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
 )
 
-func Test_wellKnownEvents_trimmed(t *testing.T) {
-	assertTrimmed := func(t *testing.T, subject string) {
-		if subject != strings.TrimSpace(subject) {
-			t.Logf("%q has leading or trailing whitespace", subject)
-			t.Fail()
+func Test_wellKnownEvent(t *testing.T) {
+	for _, eventType := range eventgrid.DefaultTypeRegistry.EventTypes() {
+		identifier, ok := wellKnownEvent(eventType)
+		if !ok {
+			t.Errorf("%q is not well known", eventType)
+			continue
+		}
+
+		if !strings.HasPrefix(identifier, "github.com/Azure/azure-sdk-for-go/services/eventgrid/") {
+			t.Errorf("%q has unexpected type identifier %q", eventType, identifier)
 		}
 	}
 
-	for k, v := range wellKnownEvents {
-		assertTrimmed(t, k)
-		assertTrimmed(t, v)
+	got, ok := wellKnownEvent("Microsoft.Storage.BlobCreated")
+	if want := "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.StorageBlobCreatedEventData"; !ok || got != want {
+		t.Errorf("got: %q want: %q", got, want)
+	}
+
+	if _, ok := wellKnownEvent("Contoso.Unknown"); ok {
+		t.Error("Contoso.Unknown should not be well known")
+	}
+}
+
+func Test_resolveType(t *testing.T) {
+	known, err := resolveType("github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid.StorageBlobCreatedEventData")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := known.(*generator.TypeStub); ok || known.NumField() == 0 {
+		t.Errorf("well-known type was not resolved in full: %v", known)
+	}
+
+	custom, err := resolveType("github.com/marstr/playground.Blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := custom.(*generator.TypeStub); !ok {
+		t.Errorf("got %T want: *eventgrid.TypeStub", custom)
+	}
+
+	if _, err = resolveType("Blob"); err == nil {
+		t.Error("expected an error")
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"time"
 
 	"github.com/gobuffalo/buffalo/generators"
 	"github.com/gobuffalo/buffalo/meta"
@@ -13,13 +14,22 @@ import (
 	"github.com/markbates/inflect"
 
 	"github.com/Azure/buffalo-azure/generators/common"
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
 )
 
 //go:generate go run ./builder/builder.go -o ./static_templates.go ./templates
 
 // Generator will parse an existing `buffalo.App` and add the relevant code
 // to make that application be ready for being subscribed to an Event Grid Topic.
-type Generator struct{}
+type Generator struct {
+	// Migrations indicates that fizz migrations creating the table used by an
+	// `eventgrid.PopStore` should be added to the application's "migrations" folder.
+	Migrations bool
+
+	// Cache indicates that the generated subscriber should keep each Event it receives, along
+	// with the outcome of handling it, using an `eventgrid.CachingSubscriber`.
+	Cache bool
+}
 
 // Run executes the Generator's main purpose, of extending a Buffalo application
 // to listen for Event Grid Events.
@@ -30,22 +40,28 @@ func (eg *Generator) Run(app meta.App, name string, types map[string]reflect.Typ
 		inflect.Name
 		PkgPath string
 		PkgSpec common.PackageSpecifier
+		Schema  string
 	}
 	flatTypes := make([]TypeMapping, 0, len(types))
 
 	ib := common.NewImportBag()
-	ib.AddImport("encoding/json")
 	ib.AddImport("errors")
 	ib.AddImport("net/http")
 	ib.AddImportWithSpecifier("github.com/Azure/buffalo-azure/sdk/eventgrid", "eg")
 	ib.AddImport("github.com/gobuffalo/buffalo")
 
 	for i, n := range types {
+		schema, err := NewSchema(n)
+		if err != nil {
+			return err
+		}
+
 		flatTypes = append(flatTypes, TypeMapping{
 			Identifier: i,
 			PkgPath:    n.PkgPath(),
 			PkgSpec:    ib.AddImport(common.PackagePath(n.PkgPath())),
 			Name:       inflect.Name(n.Name()),
+			Schema:     string(schema),
 		})
 	}
 
@@ -68,10 +84,17 @@ func (eg *Generator) Run(app meta.App, name string, types map[string]reflect.Typ
 		},
 	})
 
+	if eg.Migrations {
+		migrationName := fmt.Sprintf("%s_create_%s", time.Now().UTC().Format("20060102150405"), eventgrid.PopStoreTable)
+		g.Add(makr.NewFile(filepath.Join("migrations", migrationName+".up.fizz"), eventgrid.PopStoreMigration))
+		g.Add(makr.NewFile(filepath.Join("migrations", migrationName+".down.fizz"), eventgrid.PopStoreDownMigration))
+	}
+
 	d := make(makr.Data)
 	d["name"] = iName
 	d["types"] = flatTypes
 	d["imports"] = ib.List()
+	d["cache"] = eg.Cache
 
 	return g.Run(app.Root, d)
 }
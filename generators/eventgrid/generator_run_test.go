@@ -0,0 +1,156 @@
+package eventgrid
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/buffalo/meta"
+)
+
+// minimalApp is the "actions/app.go" file of an application, as far as `Generator.Run` is
+// concerned.
+const minimalApp = `package actions
+
+import "github.com/gobuffalo/buffalo"
+
+var app *buffalo.App
+
+func App() *buffalo.App {
+	if app == nil {
+		app = buffalo.New(buffalo.Options{})
+		app.GET("/", HomeHandler)
+	}
+	return app
+}
+`
+
+// runGenerator runs a Generator against a minimal application in a temporary directory, and
+// reads the files it created or changed, by their path relative to the application's root.
+func runGenerator(t *testing.T, subject Generator, name string) map[string]string {
+	t.Helper()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "actions"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "actions", "app.go"), []byte(minimalApp), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	app := meta.App{Root: root, ActionsPkg: "github.com/contoso/gentest/actions"}
+	err = subject.Run(app, name, map[string]reflect.Type{
+		"Microsoft.EventGrid.SubscriptionValidation": reflect.TypeOf(eventgrid.SubscriptionValidationRequest{}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	err = filepath.Walk(root, func(current string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		contents, err := ioutil.ReadFile(current)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(contents)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestGenerator_Run_Cache(t *testing.T) {
+	testCases := []struct {
+		cache bool
+		want  string
+	}{
+		{false, "Subscriber: dispatcher,"},
+		{true, "Subscriber: eg.NewCachingSubscriber(dispatcher, nil),"},
+	}
+
+	for _, tc := range testCases {
+		subscriber := runGenerator(t, Generator{Cache: tc.cache}, "ingress")["actions/ingress.go"]
+
+		if !strings.Contains(subscriber, tc.want) {
+			t.Errorf("cache %v: expected the subscriber to contain %q, got:\n%s", tc.cache, tc.want, subscriber)
+		}
+		if cached := strings.Contains(subscriber, "NewCachingSubscriber"); cached != tc.cache {
+			t.Errorf("cache %v: got a caching subscriber: %v", tc.cache, cached)
+		}
+	}
+}
+
+func TestGenerator_Run_Migrations(t *testing.T) {
+	for _, migrations := range []bool{false, true} {
+		var up, down []string
+		for name, contents := range runGenerator(t, Generator{Migrations: migrations}, "ingress") {
+			switch {
+			case !strings.HasPrefix(name, "migrations/"):
+			case strings.HasSuffix(name, ".up.fizz") && contents == eventgrid.PopStoreMigration:
+				up = append(up, name)
+			case strings.HasSuffix(name, ".down.fizz") && contents == eventgrid.PopStoreDownMigration:
+				down = append(down, name)
+			default:
+				t.Errorf("migrations %v: unexpected file %q", migrations, name)
+			}
+		}
+
+		want := 0
+		if migrations {
+			want = 1
+		}
+		if len(up) != want || len(down) != want {
+			t.Errorf("migrations %v: got up migrations %v and down migrations %v want: %d of each", migrations, up, down, want)
+		}
+	}
+}
+
+func TestGenerator_Run_Console(t *testing.T) {
+	const registration = `eventgrid.RegisterSubscriber(app, "/ingress", NewIngressSubscriber(&eventgrid.BaseSubscriber{}))`
+	if app := runGenerator(t, Generator{}, "ingress")["actions/app.go"]; !strings.Contains(app, registration) {
+		t.Fatalf("expected the subscriber to be registered using %q, got:\n%s", registration, app)
+	}
+
+	for env, want := range map[string]bool{"development": true, "production": false} {
+		app := buffalo.New(buffalo.Options{Env: env})
+		eventgrid.RegisterSubscriber(app, "/ingress", &eventgrid.BaseSubscriber{})
+
+		found := map[string]bool{}
+		for _, route := range app.Routes() {
+			if strings.TrimSuffix(route.Path, "/") == "/ingress/new" {
+				found[route.Method] = true
+			}
+		}
+
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			if found[method] != want {
+				t.Errorf("%s: got %s /ingress/new routed: %v want: %v", env, method, found[method], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package eventgrid
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// NewSchema creates a JSON Schema describing the JSON serialization of a Go type. Only the
+// name of a `TypeStub` is known, so the schema created for one only requires an object.
+func NewSchema(t reflect.Type) ([]byte, error) {
+	var schema map[string]interface{}
+	if _, ok := t.(*TypeStub); ok {
+		schema = map[string]interface{}{"type": "object"}
+	} else {
+		schema = schemaFor(t, map[reflect.Type]bool{})
+	}
+
+	schema["$schema"] = schemaDraft
+	schema["title"] = t.Name()
+
+	serialized, err := json.MarshalIndent(schema, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	// Schemas are emitted as raw string literals, which may not contain back-quotes.
+	return []byte(strings.Replace(string(serialized), "`", "\\u0060", -1)), nil
+}
+
+// schemaFor describes a type which is not a TypeStub. Types which are already being
+// described further up are not constrained, so that recursive types may be described.
+// Pointers may also be null, as "encoding/json" accepts null for them.
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		schema := schemaFor(t.Elem(), visiting)
+		if kind, ok := schema["type"].(string); ok {
+			schema["type"] = []string{kind, "null"}
+		}
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType), reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return map[string]interface{}{}
+	case t.Implements(textMarshalerType), reflect.PtrTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		addProperties(t, properties, visiting)
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// addProperties describes each field of a struct which appears in its JSON serialization.
+// The fields of embedded structs without a JSON name are promoted, as they are by
+// "encoding/json", unless a field of the outer struct has the same name.
+func addProperties(t reflect.Type, properties map[string]interface{}, visiting map[reflect.Type]bool) {
+	promoted := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addProperties(embedded, promoted, visiting)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaFor(field.Type, visiting)
+	}
+
+	for name, property := range promoted {
+		if _, ok := properties[name]; !ok {
+			properties[name] = property
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+type schemaBase struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+type schemaSubject struct {
+	schemaBase
+	Kind     int               `json:"kind"`
+	Name     *string           `json:"name,omitempty"`
+	Tags     map[string]string `json:"tags"`
+	Lines    []schemaSubject   `json:"lines"`
+	Created  time.Time         `json:"created"`
+	Raw      []byte            `json:"raw"`
+	Parent   *schemaBase       `json:"parent"`
+	Ignored  string            `json:"-"`
+	Untagged float64
+	hidden   bool
+}
+
+func TestNewSchema(t *testing.T) {
+	raw, err := NewSchema(reflect.TypeOf(schemaSubject{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = eventgrid.ParseSchema(raw); err != nil {
+		t.Errorf("generated schema is invalid: %v", err)
+	}
+
+	var schema struct {
+		Title      string                            `json:"title"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err = json.Unmarshal(raw, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Title != "schemaSubject" {
+		t.Errorf("got title: %q want: %q", schema.Title, "schemaSubject")
+	}
+
+	want := map[string]interface{}{
+		"id":       "string",
+		"kind":     "integer",
+		"name":     []interface{}{"string", "null"},
+		"tags":     "object",
+		"lines":    "array",
+		"created":  "string",
+		"raw":      "string",
+		"parent":   []interface{}{"object", "null"},
+		"Untagged": "number",
+	}
+
+	if len(schema.Properties) != len(want) {
+		t.Errorf("got properties: %v want: %v", schema.Properties, want)
+	}
+
+	for name, kind := range want {
+		if got := schema.Properties[name]["type"]; !reflect.DeepEqual(got, kind) {
+			t.Errorf("property %q got type: %v want: %v", name, got, kind)
+		}
+	}
+
+	items, _ := schema.Properties["lines"]["items"].(map[string]interface{})
+	if len(items) != 0 {
+		t.Errorf("recursive type should not be constrained, got: %v", items)
+	}
+}
+
+func TestNewSchema_Null(t *testing.T) {
+	raw, err := NewSchema(reflect.TypeOf(schemaSubject{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := eventgrid.ParseSchema(raw)
+	if err != nil {
+		t.Fatalf("generated schema is invalid: %v", err)
+	}
+
+	data := json.RawMessage(`{"id": "1", "name": null, "parent": null}`)
+
+	var decoded schemaSubject
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = schema.Validate(eventgrid.Event{Data: data}); err != nil {
+		t.Errorf("expected null to be accepted for pointer fields, as encoding/json accepts it: %v", err)
+	}
+
+	if err = schema.Validate(eventgrid.Event{Data: json.RawMessage(`{"id": null}`)}); err == nil {
+		t.Error("expected null to be rejected for a field which is not a pointer")
+	}
+}
+
+func TestNewSchema_TypeStub(t *testing.T) {
+	stub, err := NewTypeStubIdentifier("github.com/marstr/playground.Blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := NewSchema(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := eventgrid.ParseSchema(raw)
+	if err != nil {
+		t.Fatalf("generated schema is invalid: %v", err)
+	}
+
+	if err = schema.Validate(eventgrid.Event{Data: json.RawMessage(`{"anything": true}`)}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err = schema.Validate(eventgrid.Event{Data: json.RawMessage(`"blob"`)}); err == nil {
+		t.Error("expected data which is not an object to be rejected")
+	}
+}
@@ -6,5 +6,5 @@ package eventgrid
 var staticTemplates = make(TemplateCache)
 
 func init() {
-	staticTemplates["templates/actions/eventgrid_name.go.tmpl"] = []byte{112, 97, 99, 107, 97, 103, 101, 32, 97, 99, 116, 105, 111, 110, 115, 10, 10, 105, 109, 112, 111, 114, 116, 32, 40, 10, 123, 123, 32, 114, 97, 110, 103, 101, 32, 36, 105, 32, 58, 61, 32, 46, 105, 109, 112, 111, 114, 116, 115, 32, 125, 125, 9, 123, 123, 36, 105, 125, 125, 10, 123, 123, 32, 101, 110, 100, 32, 125, 125, 10, 41, 10, 10, 47, 47, 32, 77, 121, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 103, 97, 116, 104, 101, 114, 115, 32, 114, 101, 115, 112, 111, 110, 100, 115, 32, 116, 111, 32, 97, 108, 108, 32, 82, 101, 113, 117, 101, 115, 116, 115, 32, 115, 101, 110, 116, 32, 116, 111, 32, 97, 32, 112, 97, 114, 116, 105, 99, 117, 108, 97, 114, 32, 101, 110, 100, 112, 111, 105, 110, 116, 46, 10, 116, 121, 112, 101, 32, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 115, 116, 114, 117, 99, 116, 32, 123, 10, 9, 101, 103, 46, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 10, 125, 10, 10, 47, 47, 32, 78, 101, 119, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 105, 110, 115, 116, 97, 110, 116, 105, 97, 116, 101, 115, 32, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 102, 111, 114, 32, 117, 115, 101, 32, 105, 110, 32, 97, 32, 96, 98, 117, 102, 102, 97, 108, 111, 46, 65, 112, 112, 96, 46, 10, 102, 117, 110, 99, 32, 78, 101, 119, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 40, 112, 97, 114, 101, 110, 116, 32, 101, 103, 46, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 40, 99, 114, 101, 97, 116, 101, 100, 32, 42, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 123, 10, 9, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 32, 58, 61, 32, 101, 103, 46, 78, 101, 119, 84, 121, 112, 101, 68, 105, 115, 112, 97, 116, 99, 104, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 40, 112, 97, 114, 101, 110, 116, 41, 10, 10, 9, 99, 114, 101, 97, 116, 101, 100, 32, 61, 32, 38, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 123, 10, 9, 9, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 58, 32, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 44, 10, 9, 125, 10, 10, 123, 123, 32, 114, 97, 110, 103, 101, 32, 36, 116, 32, 58, 61, 32, 46, 116, 121, 112, 101, 115, 125, 125, 10, 9, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 46, 66, 105, 110, 100, 40, 34, 123, 123, 36, 116, 46, 73, 100, 101, 110, 116, 105, 102, 105, 101, 114, 125, 125, 34, 44, 32, 99, 114, 101, 97, 116, 101, 100, 46, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 41, 10, 123, 123, 101, 110, 100, 125, 125, 10, 9, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 46, 66, 105, 110, 100, 40, 101, 103, 46, 69, 118, 101, 110, 116, 84, 121, 112, 101, 87, 105, 108, 100, 99, 97, 114, 100, 44, 32, 99, 114, 101, 97, 116, 101, 100, 46, 82, 101, 99, 101, 105, 118, 101, 68, 101, 102, 97, 117, 108, 116, 41, 10, 10, 9, 114, 101, 116, 117, 114, 110, 10, 125, 10, 10, 123, 123, 32, 114, 97, 110, 103, 101, 32, 36, 116, 32, 58, 61, 32, 46, 116, 121, 112, 101, 115, 32, 125, 125, 10, 47, 47, 32, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 32, 119, 105, 108, 108, 32, 114, 101, 115, 112, 111, 110, 100, 32, 116, 111, 32, 97, 110, 32, 96, 101, 118, 101, 110, 116, 103, 114, 105, 100, 46, 69, 118, 101, 110, 116, 96, 32, 99, 97, 114, 114, 121, 105, 110, 103, 32, 97, 32, 115, 101, 114, 105, 97, 108, 105, 122, 101, 100, 32, 96, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 96, 32, 97, 115, 32, 105, 116, 115, 32, 112, 97, 121, 108, 111, 97, 100, 46, 10, 102, 117, 110, 99, 32, 40, 115, 32, 42, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 40, 99, 32, 98, 117, 102, 102, 97, 108, 111, 46, 67, 111, 110, 116, 101, 120, 116, 44, 32, 101, 32, 101, 103, 46, 69, 118, 101, 110, 116, 41, 32, 101, 114, 114, 111, 114, 32, 123, 10, 9, 118, 97, 114, 32, 112, 97, 121, 108, 111, 97, 100, 32, 123, 123, 36, 116, 46, 80, 107, 103, 83, 112, 101, 99, 125, 125, 46, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 10, 9, 105, 102, 32, 101, 114, 114, 32, 58, 61, 32, 106, 115, 111, 110, 46, 85, 110, 109, 97, 114, 115, 104, 97, 108, 40, 101, 46, 68, 97, 116, 97, 44, 32, 38, 112, 97, 121, 108, 111, 97, 100, 41, 59, 32, 101, 114, 114, 32, 33, 61, 32, 110, 105, 108, 32, 123, 10, 9, 9, 114, 101, 116, 117, 114, 110, 32, 99, 46, 69, 114, 114, 111, 114, 40, 104, 116, 116, 112, 46, 83, 116, 97, 116, 117, 115, 66, 97, 100, 82, 101, 113, 117, 101, 115, 116, 44, 32, 101, 114, 114, 111, 114, 115, 46, 78, 101, 119, 40, 34, 117, 110, 97, 98, 108, 101, 32, 116, 111, 32, 117, 110, 109, 97, 114, 115, 104, 97, 108, 32, 114, 101, 113, 117, 101, 115, 116, 32, 100, 97, 116, 97, 34, 41, 41, 10, 9, 125, 10, 10, 9, 47, 47, 32, 82, 101, 112, 108, 97, 99, 101, 32, 116, 104, 101, 32, 99, 111, 100, 101, 32, 98, 101, 108, 111, 119, 32, 119, 105, 116, 104, 32, 121, 111, 117, 114, 32, 108, 111, 103, 105, 99, 10, 9, 114, 101, 116, 117, 114, 110, 32, 99, 46, 69, 114, 114, 111, 114, 40, 104, 116, 116, 112, 46, 83, 116, 97, 116, 117, 115, 73, 110, 116, 101, 114, 110, 97, 108, 83, 101, 114, 118, 101, 114, 69, 114, 114, 111, 114, 44, 32, 101, 114, 114, 111, 114, 115, 46, 78, 101, 119, 40, 34, 110, 111, 116, 32, 105, 109, 112, 108, 101, 109, 101, 110, 116, 101, 100, 34, 41, 41, 10, 125, 10, 123, 123, 101, 110, 100, 125, 125, 10, 10, 47, 47, 32, 82, 101, 99, 101, 105, 118, 101, 68, 101, 102, 97, 117, 108, 116, 32, 119, 105, 108, 108, 32, 114, 101, 115, 112, 111, 110, 100, 32, 116, 111, 32, 97, 110, 32, 96, 101, 118, 101, 110, 116, 103, 114, 105, 100, 46, 69, 118, 101, 110, 116, 96, 32, 99, 97, 114, 114, 121, 105, 110, 103, 32, 97, 110, 121, 32, 69, 118, 101, 110, 116, 84, 121, 112, 101, 32, 97, 115, 32, 105, 116, 115, 32, 112, 97, 121, 108, 111, 97, 100, 46, 10, 102, 117, 110, 99, 32, 40, 115, 32, 42, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 82, 101, 99, 101, 105, 118, 101, 68, 101, 102, 97, 117, 108, 116, 40, 99, 32, 98, 117, 102, 102, 97, 108, 111, 46, 67, 111, 110, 116, 101, 120, 116, 44, 32, 101, 32, 101, 103, 46, 69, 118, 101, 110, 116, 41, 32, 101, 114, 114, 111, 114, 32, 123, 10, 9, 114, 101, 116, 117, 114, 110, 32, 99, 46, 69, 114, 114, 111, 114, 40, 104, 116, 116, 112, 46, 83, 116, 97, 116, 117, 115, 73, 110, 116, 101, 114, 110, 97, 108, 83, 101, 114, 118, 101, 114, 69, 114, 114, 111, 114, 44, 32, 101, 114, 114, 111, 114, 115, 46, 78, 101, 119, 40, 34, 110, 111, 116, 32, 105, 109, 112, 108, 101, 109, 101, 110, 116, 101, 100, 34, 41, 41, 10, 125, 10}
+	staticTemplates["templates/actions/eventgrid_name.go.tmpl"] = []byte{112, 97, 99, 107, 97, 103, 101, 32, 97, 99, 116, 105, 111, 110, 115, 10, 10, 105, 109, 112, 111, 114, 116, 32, 40, 10, 123, 123, 32, 114, 97, 110, 103, 101, 32, 36, 105, 32, 58, 61, 32, 46, 105, 109, 112, 111, 114, 116, 115, 32, 125, 125, 9, 123, 123, 36, 105, 125, 125, 10, 123, 123, 32, 101, 110, 100, 32, 125, 125, 10, 41, 10, 10, 47, 47, 32, 77, 121, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 103, 97, 116, 104, 101, 114, 115, 32, 114, 101, 115, 112, 111, 110, 100, 115, 32, 116, 111, 32, 97, 108, 108, 32, 82, 101, 113, 117, 101, 115, 116, 115, 32, 115, 101, 110, 116, 32, 116, 111, 32, 97, 32, 112, 97, 114, 116, 105, 99, 117, 108, 97, 114, 32, 101, 110, 100, 112, 111, 105, 110, 116, 46, 10, 116, 121, 112, 101, 32, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 115, 116, 114, 117, 99, 116, 32, 123, 10, 9, 101, 103, 46, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 10, 125, 10, 10, 47, 47, 32, 78, 101, 119, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 105, 110, 115, 116, 97, 110, 116, 105, 97, 116, 101, 115, 32, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 102, 111, 114, 32, 117, 115, 101, 32, 105, 110, 32, 97, 32, 96, 98, 117, 102, 102, 97, 108, 111, 46, 65, 112, 112, 96, 46, 10, 123, 123, 45, 32, 105, 102, 32, 46, 99, 97, 99, 104, 101, 32, 125, 125, 10, 47, 47, 32, 69, 97, 99, 104, 32, 69, 118, 101, 110, 116, 32, 105, 116, 32, 114, 101, 99, 101, 105, 118, 101, 115, 32, 105, 115, 32, 107, 101, 112, 116, 32, 102, 111, 114, 32, 97, 32, 119, 104, 105, 108, 101, 44, 32, 97, 108, 111, 110, 103, 32, 119, 105, 116, 104, 32, 116, 104, 101, 32, 111, 117, 116, 99, 111, 109, 101, 32, 111, 102, 32, 104, 97, 110, 100, 108, 105, 110, 103, 32, 105, 116, 44, 32, 115, 111, 32, 116, 104, 97, 116, 32, 114, 101, 99, 101, 110, 116, 10, 47, 47, 32, 69, 118, 101, 110, 116, 115, 32, 99, 97, 110, 32, 98, 101, 32, 105, 110, 115, 112, 101, 99, 116, 101, 100, 32, 117, 115, 105, 110, 103, 32, 116, 104, 101, 32, 76, 105, 115, 116, 32, 97, 110, 100, 32, 83, 104, 111, 119, 32, 97, 99, 116, 105, 111, 110, 115, 46, 32, 80, 114, 111, 116, 101, 99, 116, 32, 116, 104, 101, 109, 32, 117, 115, 105, 110, 103, 32, 96, 101, 103, 46, 87, 105, 116, 104, 65, 117, 116, 104, 101, 110, 116, 105, 99, 97, 116, 105, 111, 110, 96, 10, 47, 47, 32, 119, 104, 101, 114, 101, 32, 116, 104, 101, 32, 115, 117, 98, 115, 99, 114, 105, 98, 101, 114, 32, 105, 115, 32, 114, 101, 103, 105, 115, 116, 101, 114, 101, 100, 46, 10, 123, 123, 45, 32, 101, 110, 100, 32, 125, 125, 10, 102, 117, 110, 99, 32, 78, 101, 119, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 40, 112, 97, 114, 101, 110, 116, 32, 101, 103, 46, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 40, 99, 114, 101, 97, 116, 101, 100, 32, 42, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 123, 10, 9, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 32, 58, 61, 32, 101, 103, 46, 78, 101, 119, 84, 121, 112, 101, 68, 105, 115, 112, 97, 116, 99, 104, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 40, 112, 97, 114, 101, 110, 116, 41, 10, 10, 9, 99, 114, 101, 97, 116, 101, 100, 32, 61, 32, 38, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 123, 10, 123, 123, 45, 32, 105, 102, 32, 46, 99, 97, 99, 104, 101, 32, 125, 125, 10, 9, 9, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 58, 32, 101, 103, 46, 78, 101, 119, 67, 97, 99, 104, 105, 110, 103, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 40, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 44, 32, 110, 105, 108, 41, 44, 10, 123, 123, 45, 32, 101, 108, 115, 101, 32, 125, 125, 10, 9, 9, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 58, 32, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 44, 10, 123, 123, 45, 32, 101, 110, 100, 32, 125, 125, 10, 9, 125, 10, 10, 123, 123, 32, 114, 97, 110, 103, 101, 32, 36, 116, 32, 58, 61, 32, 46, 116, 121, 112, 101, 115, 125, 125, 10, 9, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 46, 66, 105, 110, 100, 84, 121, 112, 101, 100, 40, 34, 123, 123, 36, 116, 46, 73, 100, 101, 110, 116, 105, 102, 105, 101, 114, 125, 125, 34, 44, 32, 99, 114, 101, 97, 116, 101, 100, 46, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 44, 32, 101, 103, 46, 87, 105, 116, 104, 83, 99, 104, 101, 109, 97, 40, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 99, 104, 101, 109, 97, 41, 41, 10, 123, 123, 101, 110, 100, 125, 125, 10, 9, 100, 105, 115, 112, 97, 116, 99, 104, 101, 114, 46, 66, 105, 110, 100, 40, 101, 103, 46, 69, 118, 101, 110, 116, 84, 121, 112, 101, 87, 105, 108, 100, 99, 97, 114, 100, 44, 32, 99, 114, 101, 97, 116, 101, 100, 46, 82, 101, 99, 101, 105, 118, 101, 68, 101, 102, 97, 117, 108, 116, 41, 10, 10, 9, 114, 101, 116, 117, 114, 110, 10, 125, 10, 10, 123, 123, 32, 114, 97, 110, 103, 101, 32, 36, 116, 32, 58, 61, 32, 46, 116, 121, 112, 101, 115, 32, 125, 125, 10, 47, 47, 32, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 99, 104, 101, 109, 97, 32, 105, 115, 32, 97, 32, 74, 83, 79, 78, 32, 83, 99, 104, 101, 109, 97, 32, 100, 101, 115, 99, 114, 105, 98, 105, 110, 103, 32, 116, 104, 101, 32, 100, 97, 116, 97, 32, 99, 97, 114, 114, 105, 101, 100, 32, 98, 121, 32, 34, 123, 123, 36, 116, 46, 73, 100, 101, 110, 116, 105, 102, 105, 101, 114, 125, 125, 34, 32, 69, 118, 101, 110, 116, 115, 46, 10, 47, 47, 32, 69, 118, 101, 110, 116, 115, 32, 119, 104, 111, 115, 101, 32, 100, 97, 116, 97, 32, 100, 111, 101, 115, 32, 110, 111, 116, 32, 115, 97, 116, 105, 115, 102, 121, 32, 105, 116, 32, 97, 114, 101, 32, 114, 101, 106, 101, 99, 116, 101, 100, 32, 98, 101, 102, 111, 114, 101, 32, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 32, 105, 115, 32, 99, 97, 108, 108, 101, 100, 46, 32, 69, 100, 105, 116, 32, 105, 116, 32, 116, 111, 10, 47, 47, 32, 100, 101, 115, 99, 114, 105, 98, 101, 32, 116, 104, 101, 32, 100, 97, 116, 97, 32, 121, 111, 117, 32, 101, 120, 112, 101, 99, 116, 32, 109, 111, 114, 101, 32, 112, 114, 101, 99, 105, 115, 101, 108, 121, 46, 10, 118, 97, 114, 32, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 99, 104, 101, 109, 97, 32, 61, 32, 101, 103, 46, 77, 117, 115, 116, 80, 97, 114, 115, 101, 83, 99, 104, 101, 109, 97, 40, 96, 123, 123, 36, 116, 46, 83, 99, 104, 101, 109, 97, 125, 125, 96, 41, 10, 10, 47, 47, 32, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 32, 119, 105, 108, 108, 32, 114, 101, 115, 112, 111, 110, 100, 32, 116, 111, 32, 97, 110, 32, 96, 101, 118, 101, 110, 116, 103, 114, 105, 100, 46, 69, 118, 101, 110, 116, 96, 32, 99, 97, 114, 114, 121, 105, 110, 103, 32, 97, 32, 115, 101, 114, 105, 97, 108, 105, 122, 101, 100, 32, 96, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 96, 32, 97, 115, 32, 105, 116, 115, 32, 112, 97, 121, 108, 111, 97, 100, 46, 10, 102, 117, 110, 99, 32, 40, 115, 32, 42, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 82, 101, 99, 101, 105, 118, 101, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 40, 99, 32, 98, 117, 102, 102, 97, 108, 111, 46, 67, 111, 110, 116, 101, 120, 116, 44, 32, 101, 32, 101, 103, 46, 69, 118, 101, 110, 116, 44, 32, 112, 97, 121, 108, 111, 97, 100, 32, 42, 123, 123, 36, 116, 46, 80, 107, 103, 83, 112, 101, 99, 125, 125, 46, 123, 123, 36, 116, 46, 78, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 41, 32, 101, 114, 114, 111, 114, 32, 123, 10, 9, 47, 47, 32, 82, 101, 112, 108, 97, 99, 101, 32, 116, 104, 101, 32, 99, 111, 100, 101, 32, 98, 101, 108, 111, 119, 32, 119, 105, 116, 104, 32, 121, 111, 117, 114, 32, 108, 111, 103, 105, 99, 10, 9, 114, 101, 116, 117, 114, 110, 32, 99, 46, 69, 114, 114, 111, 114, 40, 104, 116, 116, 112, 46, 83, 116, 97, 116, 117, 115, 73, 110, 116, 101, 114, 110, 97, 108, 83, 101, 114, 118, 101, 114, 69, 114, 114, 111, 114, 44, 32, 101, 114, 114, 111, 114, 115, 46, 78, 101, 119, 40, 34, 110, 111, 116, 32, 105, 109, 112, 108, 101, 109, 101, 110, 116, 101, 100, 34, 41, 41, 10, 125, 10, 123, 123, 101, 110, 100, 125, 125, 10, 10, 47, 47, 32, 82, 101, 99, 101, 105, 118, 101, 68, 101, 102, 97, 117, 108, 116, 32, 119, 105, 108, 108, 32, 114, 101, 115, 112, 111, 110, 100, 32, 116, 111, 32, 97, 110, 32, 96, 101, 118, 101, 110, 116, 103, 114, 105, 100, 46, 69, 118, 101, 110, 116, 96, 32, 99, 97, 114, 114, 121, 105, 110, 103, 32, 97, 110, 121, 32, 69, 118, 101, 110, 116, 84, 121, 112, 101, 32, 97, 115, 32, 105, 116, 115, 32, 112, 97, 121, 108, 111, 97, 100, 46, 10, 102, 117, 110, 99, 32, 40, 115, 32, 42, 123, 123, 36, 46, 110, 97, 109, 101, 46, 67, 97, 109, 101, 108, 125, 125, 83, 117, 98, 115, 99, 114, 105, 98, 101, 114, 41, 32, 82, 101, 99, 101, 105, 118, 101, 68, 101, 102, 97, 117, 108, 116, 40, 99, 32, 98, 117, 102, 102, 97, 108, 111, 46, 67, 111, 110, 116, 101, 120, 116, 44, 32, 101, 32, 101, 103, 46, 69, 118, 101, 110, 116, 41, 32, 101, 114, 114, 111, 114, 32, 123, 10, 9, 114, 101, 116, 117, 114, 110, 32, 99, 46, 69, 114, 114, 111, 114, 40, 104, 116, 116, 112, 46, 83, 116, 97, 116, 117, 115, 73, 110, 116, 101, 114, 110, 97, 108, 83, 101, 114, 118, 101, 114, 69, 114, 114, 111, 114, 44, 32, 101, 114, 114, 111, 114, 115, 46, 78, 101, 119, 40, 34, 110, 111, 116, 32, 105, 109, 112, 108, 101, 109, 101, 110, 116, 101, 100, 34, 41, 41, 10, 125, 10}
 }
@@ -1,5 +1,7 @@
 module github.com/Azure/buffalo-azure
 
+go 1.27.1
+
 require (
 	github.com/Azure/azure-sdk-for-go v18.0.0+incompatible
 	github.com/Azure/buffalo-azure/sdk v0.1.0
@@ -10,7 +12,6 @@ require (
 	github.com/gobuffalo/pop v4.8.4+incompatible
 	github.com/joho/godotenv v1.3.0
 	github.com/markbates/inflect v1.0.1
-	github.com/marstr/collection v0.3.3 // indirect
 	github.com/marstr/randname v0.0.0-20180611202505-48a63b6052f1
 	github.com/mitchellh/go-homedir v1.0.0
 	github.com/sirupsen/logrus v1.1.1
@@ -18,4 +19,124 @@ require (
 	github.com/spf13/viper v1.2.1
 )
 
+require (
+	github.com/BurntSushi/toml v0.3.1 // indirect
+	github.com/Masterminds/semver v1.4.2 // indirect
+	github.com/ajg/form v0.0.0-20160822230020-523a5da1a92f // indirect
+	github.com/cockroachdb/apd v1.1.0 // indirect
+	github.com/cockroachdb/cockroach-go v0.0.0-20181001143604-e0a95dfd547c // indirect
+	github.com/codegangsta/negroni v1.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
+	github.com/dustin/go-humanize v1.0.0 // indirect
+	github.com/fatih/color v1.7.0 // indirect
+	github.com/fatih/structs v1.1.0 // indirect
+	github.com/fsnotify/fsnotify v1.4.7 // indirect
+	github.com/go-sql-driver/mysql v1.4.0 // indirect
+	github.com/gobuffalo/buffalo-pop v1.0.5 // indirect
+	github.com/gobuffalo/envy v1.6.5 // indirect
+	github.com/gobuffalo/events v1.0.7 // indirect
+	github.com/gobuffalo/fizz v1.0.12 // indirect
+	github.com/gobuffalo/flect v0.0.0-20181007231023-ae7ed6bfe683 // indirect
+	github.com/gobuffalo/genny v0.0.0-20181012161047-33e5f43d83a6 // indirect
+	github.com/gobuffalo/github_flavored_markdown v1.0.5 // indirect
+	github.com/gobuffalo/httptest v1.0.2 // indirect
+	github.com/gobuffalo/licenser v0.0.0-20180924033006-eae28e638a42 // indirect
+	github.com/gobuffalo/mapi v1.0.1 // indirect
+	github.com/gobuffalo/mw-basicauth v1.0.3 // indirect
+	github.com/gobuffalo/mw-contenttype v0.0.0-20180802152300-74f5a47f4d56 // indirect
+	github.com/gobuffalo/mw-csrf v0.0.0-20180802151833-446ff26e108b // indirect
+	github.com/gobuffalo/mw-forcessl v0.0.0-20180802152810-73921ae7a130 // indirect
+	github.com/gobuffalo/mw-i18n v0.0.0-20180802152014-e3060b7e13d6 // indirect
+	github.com/gobuffalo/mw-paramlogger v0.0.0-20181005191442-d6ee392ec72e // indirect
+	github.com/gobuffalo/mw-tokenauth v0.0.0-20181001105134-8545f626c189 // indirect
+	github.com/gobuffalo/packr v1.13.7 // indirect
+	github.com/gobuffalo/plush v3.7.20+incompatible // indirect
+	github.com/gobuffalo/release v1.0.38 // indirect
+	github.com/gobuffalo/shoulders v1.0.1 // indirect
+	github.com/gobuffalo/tags v2.0.11+incompatible // indirect
+	github.com/gobuffalo/uuid v2.0.4+incompatible // indirect
+	github.com/gobuffalo/validate v2.0.3+incompatible // indirect
+	github.com/gobuffalo/x v0.0.0-20181007152206-913e47c59ca7 // indirect
+	github.com/gofrs/uuid v3.1.0+incompatible // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/gorilla/context v1.1.1 // indirect
+	github.com/gorilla/mux v1.6.2 // indirect
+	github.com/gorilla/pat v0.0.0-20180118222023-199c85a7f6d1 // indirect
+	github.com/gorilla/securecookie v1.1.1 // indirect
+	github.com/gorilla/sessions v1.1.3 // indirect
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/hpcloud/tail v1.0.0 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/jackc/fake v0.0.0-20150926172116-812a484cc733 // indirect
+	github.com/jackc/pgx v3.2.0+incompatible // indirect
+	github.com/jmoiron/sqlx v1.2.0 // indirect
+	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/kr/pty v1.1.1 // indirect
+	github.com/kr/text v0.1.0 // indirect
+	github.com/lib/pq v1.0.0 // indirect
+	github.com/magiconair/properties v1.8.0 // indirect
+	github.com/markbates/deplist v1.0.5 // indirect
+	github.com/markbates/going v1.0.2 // indirect
+	github.com/markbates/grift v1.0.4 // indirect
+	github.com/markbates/hmax v1.0.0 // indirect
+	github.com/markbates/oncer v0.0.0-20181014194634-05fccaae8fc4 // indirect
+	github.com/markbates/refresh v1.4.10 // indirect
+	github.com/markbates/sigtx v1.0.0 // indirect
+	github.com/markbates/willie v1.0.9 // indirect
+	github.com/marstr/collection v0.3.3 // indirect
+	github.com/mattn/go-colorable v0.0.9 // indirect
+	github.com/mattn/go-isatty v0.0.4 // indirect
+	github.com/mattn/go-sqlite3 v1.9.0 // indirect
+	github.com/microcosm-cc/bluemonday v1.0.1 // indirect
+	github.com/mitchellh/mapstructure v1.1.2 // indirect
+	github.com/monoculum/formam v0.0.0-20180901015400-4e68be1d79ba // indirect
+	github.com/nicksnyder/go-i18n v1.10.0 // indirect
+	github.com/onsi/ginkgo v1.6.0 // indirect
+	github.com/onsi/gomega v1.4.2 // indirect
+	github.com/pelletier/go-toml v1.2.0 // indirect
+	github.com/pkg/errors v0.8.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/satori/go.uuid v1.2.0 // indirect
+	github.com/serenize/snaker v0.0.0-20171204205717-a683aaf2d516 // indirect
+	github.com/sergi/go-diff v1.0.0 // indirect
+	github.com/shopspring/decimal v0.0.0-20180709203117-cd690d0c9e24 // indirect
+	github.com/shurcooL/go v0.0.0-20180423040247-9e1955d9fb6e // indirect
+	github.com/shurcooL/go-goon v0.0.0-20170922171312-37c2f522c041 // indirect
+	github.com/shurcooL/highlight_diff v0.0.0-20170515013008-09bb4053de1b // indirect
+	github.com/shurcooL/highlight_go v0.0.0-20170515013102-78fb10f4a5f8 // indirect
+	github.com/shurcooL/octicon v0.0.0-20180602230221-c42b0e3b24d9 // indirect
+	github.com/shurcooL/sanitized_anchor_name v0.0.0-20170918181015-86672fcb3f95 // indirect
+	github.com/sourcegraph/annotate v0.0.0-20160123013949-f4cad6c6324d // indirect
+	github.com/sourcegraph/syntaxhighlight v0.0.0-20170531221838-bd320f5d308e // indirect
+	github.com/spf13/afero v1.1.2 // indirect
+	github.com/spf13/cast v1.2.0 // indirect
+	github.com/spf13/jwalterweatherman v1.0.0 // indirect
+	github.com/spf13/pflag v1.0.3 // indirect
+	github.com/stretchr/testify v1.2.2 // indirect
+	github.com/unrolled/secure v0.0.0-20181005190816-ff9db2ff917f // indirect
+	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
+	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
+	github.com/xeipuuv/gojsonschema v1.1.0 // indirect
+	go.etcd.io/bbolt v1.3.5 // indirect
+	golang.org/x/crypto v0.0.0-20181015023909-0c41d7ab0a0e // indirect
+	golang.org/x/net v0.0.0-20181011144130-49bb7cea24b1 // indirect
+	golang.org/x/sync v0.0.0-20180314180146-1d60e4601c6f // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	golang.org/x/tools v0.0.0-20181013182035-5e66757b835f // indirect
+	google.golang.org/appengine v1.2.0 // indirect
+	gopkg.in/airbrake/gobrake.v2 v2.0.9 // indirect
+	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/fsnotify.v1 v1.4.7 // indirect
+	gopkg.in/gemnasium/logrus-airbrake-hook.v2 v2.1.2 // indirect
+	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df // indirect
+	gopkg.in/mail.v2 v2.0.0-20180731213649-a0242b2233b4 // indirect
+	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
+	gopkg.in/yaml.v2 v2.2.1 // indirect
+)
+
 replace github.com/Azure/buffalo-azure/sdk => ./sdk
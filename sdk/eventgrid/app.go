@@ -4,18 +4,95 @@ import (
 	"github.com/gobuffalo/buffalo"
 )
 
+// SubscriberOption customizes the routes that are created for a `Subscriber` by
+// `RegisterSubscriber` and `App.Subscriber`.
+type SubscriberOption func(*subscriberConfig)
+
+type subscriberConfig struct {
+	webHook       WebHookValidation
+	validation    []ValidationOption
+	authenticator Authenticator
+}
+
+func newSubscriberConfig(opts []SubscriberOption) *subscriberConfig {
+	created := &subscriberConfig{}
+	for _, opt := range opts {
+		opt(created)
+	}
+	return created
+}
+
+// authenticate wraps an action of a `Subscriber` with the middleware appropriate for this
+// configuration, so that only trusted publishers may use it.
+func (cfg *subscriberConfig) authenticate(h buffalo.Handler) buffalo.Handler {
+	if cfg.authenticator != nil {
+		h = AuthenticationMiddleware(cfg.authenticator)(h)
+	}
+	return h
+}
+
+// WithAllowedOrigins replaces the list of origins that will be granted permission
+// to deliver CloudEvents during the web hook validation handshake. By default, only
+// `DefaultWebHookOrigin` is allowed.
+func WithAllowedOrigins(origins ...string) SubscriberOption {
+	return func(cfg *subscriberConfig) {
+		cfg.webHook.AllowedOrigins = origins
+	}
+}
+
+// WithAllowedRate sets the number of requests per minute that an origin will be
+// granted during the web hook validation handshake.
+func WithAllowedRate(rate int) SubscriberOption {
+	return func(cfg *subscriberConfig) {
+		cfg.webHook.AllowedRate = rate
+	}
+}
+
+// WithValidationOptions customizes how the subscription validation handshake is answered
+// by the routes created by `RegisterSubscriber`.
+func WithValidationOptions(opts ...ValidationOption) SubscriberOption {
+	return func(cfg *subscriberConfig) {
+		cfg.validation = append(cfg.validation, opts...)
+	}
+}
+
+// WithAuthentication requires every event delivered to a `Subscriber` to be accepted by an
+// `Authenticator`. Requests that are not accepted are rejected with an HTTP 401 Status Code
+// before any handler runs, including subscription validation. The same is required of
+// requests to list or show the Events a Subscriber has received.
+func WithAuthentication(a Authenticator) SubscriberOption {
+	return func(cfg *subscriberConfig) {
+		cfg.authenticator = a
+	}
+}
+
 // RegisterSubscriber updates a `buffalo.App` to route requests to a particular
 // subscriber.
+// GET requests to the route are handled by the List action of the subscriber, which may use
+// `ListEvents` to filter and page through the Events it has kept using query parameters. As
+// those Events may carry sensitive data, the List and Show actions are authenticated in the
+// same way as Receive, when `WithAuthentication` is used.
+// In the "development" environment, GET and POST requests to "new" beneath the route are
+// handled by the New action of the subscriber, which shows a console for sending it Events.
+// Those Events pass through the same middleware as the ones delivered to the route.
 // This method is the spiritual equivalent of `App.Resource`:
 // https://godoc.org/github.com/gobuffalo/buffalo#App.Resource
-func RegisterSubscriber(app *buffalo.App, route string, s Subscriber) *buffalo.App {
+func RegisterSubscriber(app *buffalo.App, route string, s Subscriber, opts ...SubscriberOption) *buffalo.App {
+	cfg := newSubscriberConfig(opts)
 	group := app.Group(route)
 
 	route = "/"
 
-	group.POST(route, SubscriptionValidationMiddleware(s.Receive))
-	group.GET(route, s.List)
-	group.GET(route+"{event_id}", s.Show)
+	receive := cfg.authenticate(SubscriptionValidationMiddleware(s.Receive, cfg.validation...))
+	group.POST(route, receive)
+	group.OPTIONS(route, cfg.webHook.Handler)
+	if app.Env == "development" {
+		console := consoleHandler(s, receive)
+		group.GET(route+"new", console)
+		group.POST(route+"new", console)
+	}
+	group.GET(route, cfg.authenticate(s.List))
+	group.GET(route+"{event_id}", cfg.authenticate(s.Show))
 
 	return group
 }
@@ -0,0 +1,483 @@
+package eventgrid
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// Authenticator decides whether or not a request was sent by a trusted publisher.
+type Authenticator interface {
+	Authenticate(*http.Request) error
+}
+
+// AuthenticatorFunc allows a plain function to be used as an `Authenticator`.
+type AuthenticatorFunc func(*http.Request) error
+
+// Authenticate calls f(r).
+func (f AuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// AuthenticationMiddleware provides a `buffalo.MiddlewareFunc` which will reject any request that
+// is not accepted by an `Authenticator` with an HTTP 401 Status Code, before the next `buffalo.Handler`
+// is run.
+func AuthenticationMiddleware(a Authenticator) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			if err := a.Authenticate(c.Request()); err != nil {
+				return c.Error(http.StatusUnauthorized, err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// ErrMissingCredentials is returned by the built-in Authenticators when a request does not
+// carry any of the credentials they look for.
+var ErrMissingCredentials = errors.New("request does not carry credentials")
+
+// ErrInvalidCredentials is returned by the built-in Authenticators when a request carries
+// credentials that do not match the ones expected.
+var ErrInvalidCredentials = errors.New("request carries invalid credentials")
+
+// SharedSecretAuthenticator accepts requests that carry a pre-shared secret in either a query
+// parameter or a header. Appending a secret to the endpoint URL of an Event Subscription is the
+// pattern recommended by the Event Grid documentation:
+// https://docs.microsoft.com/en-us/azure/event-grid/security-authentication#using-client-secret-as-a-query-parameter
+type SharedSecretAuthenticator struct {
+	// Secret is the value that a request must present.
+	Secret string
+
+	// QueryParameter is the name of the query parameter that may carry the secret.
+	QueryParameter string
+
+	// Header is the name of the header that may carry the secret.
+	Header string
+}
+
+// Authenticate ensures that a request carries the expected secret in either the configured
+// query parameter or the configured header.
+func (a SharedSecretAuthenticator) Authenticate(r *http.Request) error {
+	if a.Secret == "" {
+		return errors.New("no shared secret has been configured")
+	}
+
+	var presented []string
+	if a.QueryParameter != "" {
+		presented = append(presented, r.URL.Query()[a.QueryParameter]...)
+	}
+	if a.Header != "" {
+		presented = append(presented, r.Header[http.CanonicalHeaderKey(a.Header)]...)
+	}
+
+	if len(presented) == 0 {
+		return ErrMissingCredentials
+	}
+
+	for _, candidate := range presented {
+		if secureEqual(candidate, a.Secret) {
+			return nil
+		}
+	}
+	return ErrInvalidCredentials
+}
+
+// SASAuthenticator accepts requests that carry either an `aeg-sas-key` header holding Key, or an
+// `aeg-sas-token` header holding an unexpired token signed with Key.
+//
+// External documentation on these headers can be found here:
+// https://docs.microsoft.com/en-us/azure/event-grid/security-authentication#authenticate-publishing-clients-using-sas-or-key
+type SASAuthenticator struct {
+	// Key is the base64 encoded access key shared with the publisher.
+	Key string
+
+	// Resource, when not empty, must be a prefix of the resource named in an `aeg-sas-token`.
+	Resource string
+}
+
+// sasExpirationLayout is the format used for the expiration of an `aeg-sas-token`.
+const sasExpirationLayout = "1/2/2006 3:04:05 PM"
+
+// Authenticate ensures that a request carries either the expected key, or a valid token.
+func (a SASAuthenticator) Authenticate(r *http.Request) error {
+	if a.Key == "" {
+		return errors.New("no access key has been configured")
+	}
+
+	if key := r.Header.Get("Aeg-Sas-Key"); key != "" {
+		if secureEqual(key, a.Key) {
+			return nil
+		}
+		return ErrInvalidCredentials
+	}
+
+	if token := r.Header.Get("Aeg-Sas-Token"); token != "" {
+		return a.verifyToken(token, time.Now())
+	}
+
+	return ErrMissingCredentials
+}
+
+// SignSASToken creates a value suitable for the `aeg-sas-token` header, which grants access to a
+// resource until a particular moment.
+func SignSASToken(key, resource string, expiration time.Time) string {
+	unsigned := fmt.Sprintf("r=%s&e=%s", url.QueryEscape(resource), url.QueryEscape(expiration.UTC().Format(sasExpirationLayout)))
+	return fmt.Sprintf("%s&s=%s", unsigned, url.QueryEscape(signSAS(key, unsigned)))
+}
+
+func (a SASAuthenticator) verifyToken(token string, now time.Time) error {
+	sigStart := strings.LastIndex(token, "&s=")
+	if sigStart < 0 {
+		return fmt.Errorf("%v: malformed aeg-sas-token", ErrInvalidCredentials)
+	}
+	unsigned := token[:sigStart]
+
+	signature, err := url.QueryUnescape(token[sigStart+len("&s="):])
+	if err != nil {
+		return fmt.Errorf("%v: malformed aeg-sas-token signature", ErrInvalidCredentials)
+	}
+
+	if !secureEqual(signature, signSAS(a.Key, unsigned)) {
+		return ErrInvalidCredentials
+	}
+
+	fields, err := url.ParseQuery(unsigned)
+	if err != nil {
+		return fmt.Errorf("%v: malformed aeg-sas-token", ErrInvalidCredentials)
+	}
+
+	expiration, err := time.Parse(sasExpirationLayout, fields.Get("e"))
+	if err != nil {
+		return fmt.Errorf("%v: malformed aeg-sas-token expiration", ErrInvalidCredentials)
+	}
+	if now.After(expiration) {
+		return fmt.Errorf("%v: aeg-sas-token expired at %v", ErrInvalidCredentials, expiration)
+	}
+
+	if a.Resource != "" && !strings.HasPrefix(strings.ToLower(fields.Get("r")), strings.ToLower(a.Resource)) {
+		return fmt.Errorf("%v: aeg-sas-token was issued for another resource", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+func signSAS(key, unsigned string) string {
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		decodedKey = []byte(key)
+	}
+
+	mac := hmac.New(sha256.New, decodedKey)
+	mac.Write([]byte(unsigned))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// BearerTokenDefaultRefreshInterval is the longest amount of time a JWKS document will be
+// used before it is fetched again, when no other value has been specified.
+const BearerTokenDefaultRefreshInterval = 24 * time.Hour
+
+// BearerTokenDefaultMinRefreshInterval is the shortest amount of time between two fetches of a
+// JWKS document, when no other value has been specified.
+const BearerTokenDefaultMinRefreshInterval = time.Minute
+
+// BearerTokenDefaultFetchTimeout is the longest amount of time a fetch of a JWKS document may
+// take, when no other value has been specified.
+const BearerTokenDefaultFetchTimeout = 10 * time.Second
+
+// BearerTokenAuthenticator accepts requests that carry an RS256 signed JSON Web Token issued by
+// Azure Active Directory in their `Authorization` header. This is how Event Grid authenticates
+// itself when delivering to a secured web hook.
+//
+// External documentation on secured web hook delivery can be found here:
+// https://docs.microsoft.com/en-us/azure/event-grid/secure-webhook-delivery
+type BearerTokenAuthenticator struct {
+	// JWKSURL is the location of the JSON Web Key Set document used to verify signatures.
+	// For example: https://login.microsoftonline.com/common/discovery/v2.0/keys
+	JWKSURL string
+
+	// Audience must be present in the "aud" claim of the token. It is required: when empty,
+	// every token is rejected.
+	Audience string
+
+	// Issuer, when not empty, must match the "iss" claim of the token.
+	Issuer string
+
+	// Client is used to fetch the JWKS document. When nil, `http.DefaultClient` is used.
+	Client *http.Client
+
+	// RefreshInterval is the longest amount of time a JWKS document will be used before it
+	// is fetched again. When zero or less, `BearerTokenDefaultRefreshInterval` is used.
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval is the shortest amount of time between two fetches of the JWKS
+	// document, so that tokens naming unknown keys can not cause it to be fetched on every
+	// request. When zero or less, `BearerTokenDefaultMinRefreshInterval` is used.
+	MinRefreshInterval time.Duration
+
+	// FetchTimeout is the longest amount of time a fetch of the JWKS document may take,
+	// regardless of any timeout configured on `Client`. When zero or less,
+	// `BearerTokenDefaultFetchTimeout` is used.
+	FetchTimeout time.Duration
+
+	lock      sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetched   time.Time
+	attempted time.Time
+	fetching  *jwksFetch
+}
+
+// jwksFetch is a fetch of a JWKS document that is in progress. `err` may only be read once
+// `done` has been closed.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// Authenticate ensures that a request carries a bearer token that was signed by one of the keys
+// in the JWKS document, is currently valid, and was issued for the expected audience.
+func (a *BearerTokenAuthenticator) Authenticate(r *http.Request) error {
+	const prefix = "bearer "
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return ErrMissingCredentials
+	}
+
+	return a.verify(r.Context(), strings.TrimSpace(authorization[len(prefix):]), time.Now())
+}
+
+func (a *BearerTokenAuthenticator) verify(ctx context.Context, token string, now time.Time) error {
+	if a.Audience == "" {
+		return errors.New("no audience has been configured")
+	}
+
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return fmt.Errorf("%v: malformed bearer token", ErrInvalidCredentials)
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeJWTSegment(segments[0], &header); err != nil {
+		return fmt.Errorf("%v: malformed bearer token header", ErrInvalidCredentials)
+	}
+	if header.Algorithm != "RS256" {
+		return fmt.Errorf("%v: unsupported signing algorithm %q", ErrInvalidCredentials, header.Algorithm)
+	}
+
+	key, err := a.key(ctx, header.KeyID, now)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return fmt.Errorf("%v: malformed bearer token signature", ErrInvalidCredentials)
+	}
+
+	digest := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	var claims struct {
+		Audience  audienceClaim `json:"aud"`
+		Issuer    string        `json:"iss"`
+		Expires   int64         `json:"exp"`
+		NotBefore int64         `json:"nbf"`
+	}
+	if err = decodeJWTSegment(segments[1], &claims); err != nil {
+		return fmt.Errorf("%v: malformed bearer token claims", ErrInvalidCredentials)
+	}
+
+	const leeway = 5 * time.Minute
+	if claims.Expires == 0 || now.Add(-leeway).After(time.Unix(claims.Expires, 0)) {
+		return fmt.Errorf("%v: bearer token has expired", ErrInvalidCredentials)
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("%v: bearer token is not yet valid", ErrInvalidCredentials)
+	}
+	if !claims.Audience.contains(a.Audience) {
+		return fmt.Errorf("%v: bearer token was issued for another audience", ErrInvalidCredentials)
+	}
+	if a.Issuer != "" && claims.Issuer != a.Issuer {
+		return fmt.Errorf("%v: bearer token was issued by %q", ErrInvalidCredentials, claims.Issuer)
+	}
+	return nil
+}
+
+// key finds the public key with a particular identifier, fetching the JWKS document again
+// if it is stale or does not contain that identifier. The document is fetched at most once
+// every `MinRefreshInterval`, in the background, and callers which need it while it is being
+// fetched wait for that fetch instead of starting another. Callers stop waiting when their
+// own Context is done, and the fetch itself is abandoned after `FetchTimeout`.
+func (a *BearerTokenAuthenticator) key(ctx context.Context, id string, now time.Time) (*rsa.PublicKey, error) {
+	refresh := a.RefreshInterval
+	if refresh <= 0 {
+		refresh = BearerTokenDefaultRefreshInterval
+	}
+
+	minRefresh := a.MinRefreshInterval
+	if minRefresh <= 0 {
+		minRefresh = BearerTokenDefaultMinRefreshInterval
+	}
+
+	a.lock.Lock()
+	var waited *jwksFetch
+	for {
+		if a.fetching == nil {
+			key, ok := a.keys[id]
+			stale := a.fetched.IsZero() || now.Sub(a.fetched) >= refresh
+			throttled := !a.attempted.IsZero() && now.Sub(a.attempted) < minRefresh
+			if (ok && !stale) || throttled || waited != nil {
+				a.lock.Unlock()
+				switch {
+				case ok:
+					return key, nil
+				case waited != nil && waited.err != nil:
+					return nil, waited.err
+				default:
+					return nil, fmt.Errorf("%v: unknown signing key %q", ErrInvalidCredentials, id)
+				}
+			}
+
+			a.fetching, a.attempted = &jwksFetch{done: make(chan struct{})}, now
+			go a.fetch(a.fetching, now)
+		}
+
+		waited = a.fetching
+		a.lock.Unlock()
+		select {
+		case <-waited.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		a.lock.Lock()
+	}
+}
+
+// fetch retrieves the JWKS document on behalf of every caller waiting on f.
+func (a *BearerTokenAuthenticator) fetch(f *jwksFetch, now time.Time) {
+	timeout := a.FetchTimeout
+	if timeout <= 0 {
+		timeout = BearerTokenDefaultFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	keys, err := fetchJWKS(ctx, a.Client, a.JWKSURL)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if err == nil {
+		a.keys, a.fetched = keys, now
+	}
+	f.err = err
+	a.fetching = nil
+	close(f.done)
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, location string) (map[string]*rsa.PublicKey, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch JWKS document, status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []struct {
+			KeyType  string `json:"kty"`
+			KeyID    string `json:"kid"`
+			Modulus  string `json:"n"`
+			Exponent string `json:"e"`
+		} `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(document.Keys))
+	for _, k := range document.Keys {
+		if k.KeyType != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.Modulus)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.Exponent)
+		if err != nil {
+			continue
+		}
+
+		keys[k.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func decodeJWTSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// audienceClaim accommodates the "aud" claim being either a single string or a list of them.
+type audienceClaim []string
+
+func (a *audienceClaim) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audienceClaim{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+func (a audienceClaim) contains(audience string) bool {
+	for _, candidate := range a {
+		if candidate == audience {
+			return true
+		}
+	}
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,426 @@
+package eventgrid_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func TestSharedSecretAuthenticator_Authenticate(t *testing.T) {
+	subject := eventgrid.SharedSecretAuthenticator{
+		Secret:         "buffalo",
+		QueryParameter: "code",
+		Header:         "X-Contoso-Secret",
+	}
+
+	testCases := []struct {
+		name   string
+		target string
+		header string
+		want   bool
+	}{
+		{"query", "/events?code=buffalo", "", true},
+		{"header", "/events", "buffalo", true},
+		{"wrong-query", "/events?code=bison", "", false},
+		{"wrong-header", "/events", "bison", false},
+		{"missing", "/events", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.target, nil)
+			if tc.header != "" {
+				req.Header.Add("X-Contoso-Secret", tc.header)
+			}
+
+			if got := subject.Authenticate(req) == nil; got != tc.want {
+				t.Errorf("got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSASAuthenticator_Authenticate(t *testing.T) {
+	const key = "YnVmZmFsby1henVyZQ=="
+	const resource = "https://contoso.westus2-1.eventgrid.azure.net/api/events"
+
+	subject := eventgrid.SASAuthenticator{Key: key, Resource: resource}
+
+	testCases := []struct {
+		name   string
+		header string
+		value  string
+		want   bool
+	}{
+		{"key", "aeg-sas-key", key, true},
+		{"wrong-key", "aeg-sas-key", "bm90IHRoZSBrZXk=", false},
+		{"token", "aeg-sas-token", eventgrid.SignSASToken(key, resource, time.Now().Add(time.Hour)), true},
+		{"expired-token", "aeg-sas-token", eventgrid.SignSASToken(key, resource, time.Now().Add(-time.Hour)), false},
+		{"forged-token", "aeg-sas-token", eventgrid.SignSASToken("bm90IHRoZSBrZXk=", resource, time.Now().Add(time.Hour)), false},
+		{"other-resource", "aeg-sas-token", eventgrid.SignSASToken(key, "https://fabrikam.westus2-1.eventgrid.azure.net/api/events", time.Now().Add(time.Hour)), false},
+		{"missing", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/events", nil)
+			if tc.header != "" {
+				req.Header.Add(tc.header, tc.value)
+			}
+
+			if got := subject.Authenticate(req) == nil; got != tc.want {
+				t.Errorf("got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// signBearerToken creates an RS256 signed JSON Web Token, naming kid as the key which signed it.
+func signBearerToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		raw, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	unsigned := encode(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestBearerTokenAuthenticator_Authenticate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+
+	sign := func(kid string, claims map[string]interface{}) string {
+		return signBearerToken(t, key, kid, claims)
+	}
+
+	const audience = "api://buffalo-azure"
+	valid := map[string]interface{}{
+		"aud": audience,
+		"iss": "https://sts.windows.net/tenant/",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		copied := make(map[string]interface{}, len(valid))
+		for k, v := range valid {
+			copied[k] = v
+		}
+		copied[name] = value
+		return copied
+	}
+
+	subject := &eventgrid.BearerTokenAuthenticator{
+		JWKSURL:  jwks.URL,
+		Audience: audience,
+		Issuer:   "https://sts.windows.net/tenant/",
+	}
+
+	testCases := []struct {
+		name          string
+		authorization string
+		want          bool
+	}{
+		{"valid", "Bearer " + sign("key1", valid), true},
+		{"audience-list", "Bearer " + sign("key1", withClaim("aud", []string{"other", audience})), true},
+		{"wrong-audience", "Bearer " + sign("key1", withClaim("aud", "other")), false},
+		{"wrong-issuer", "Bearer " + sign("key1", withClaim("iss", "https://sts.windows.net/other/")), false},
+		{"expired", "Bearer " + sign("key1", withClaim("exp", time.Now().Add(-time.Hour).Unix())), false},
+		{"unknown-key", "Bearer " + sign("key2", valid), false},
+		{"tampered", "Bearer " + strings.Replace(sign("key1", valid), ".", ".e30", 1), false},
+		{"missing", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/events", nil)
+			if tc.authorization != "" {
+				req.Header.Add("Authorization", tc.authorization)
+			}
+
+			err := subject.Authenticate(req)
+			if got := err == nil; got != tc.want {
+				t.Errorf("got: %v want: %v (%v)", got, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestBearerTokenAuthenticator_Refresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int32
+	var published atomic.Value
+	published.Store([]string{"key1"})
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(10 * time.Millisecond)
+
+		var keys []map[string]string
+		for _, kid := range published.Load().([]string) {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer jwks.Close()
+
+	const audience = "api://buffalo-azure"
+	authenticate := func(subject *eventgrid.BearerTokenAuthenticator, kid string) error {
+		req := httptest.NewRequest(http.MethodPost, "/events", nil)
+		req.Header.Add("Authorization", "Bearer "+signBearerToken(t, key, kid, map[string]interface{}{
+			"aud": audience,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}))
+		return subject.Authenticate(req)
+	}
+
+	t.Run("unknown keys", func(t *testing.T) {
+		atomic.StoreInt32(&fetches, 0)
+		subject := &eventgrid.BearerTokenAuthenticator{JWKSURL: jwks.URL, Audience: audience}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := authenticate(subject, fmt.Sprintf("unknown%d", i)); err == nil {
+					t.Error("expected a token signed with an unknown key to be rejected")
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if err := authenticate(subject, "key1"); err != nil {
+			t.Error(err)
+		}
+
+		if got := atomic.LoadInt32(&fetches); got != 1 {
+			t.Errorf("got %d fetches of the JWKS document want: 1", got)
+		}
+	})
+
+	t.Run("rotated keys", func(t *testing.T) {
+		atomic.StoreInt32(&fetches, 0)
+		defer published.Store([]string{"key1"})
+
+		subject := &eventgrid.BearerTokenAuthenticator{
+			JWKSURL:            jwks.URL,
+			Audience:           audience,
+			MinRefreshInterval: 50 * time.Millisecond,
+		}
+
+		if err := authenticate(subject, "key1"); err != nil {
+			t.Fatal(err)
+		}
+
+		published.Store([]string{"key1", "key2"})
+		if err := authenticate(subject, "key2"); err == nil {
+			t.Error("expected the JWKS document not to be fetched again so soon")
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		if err := authenticate(subject, "key2"); err != nil {
+			t.Errorf("expected the rotated key to be found once the JWKS document was fetched again: %v", err)
+		}
+
+		if got := atomic.LoadInt32(&fetches); got != 2 {
+			t.Errorf("got %d fetches of the JWKS document want: 2", got)
+		}
+	})
+
+	t.Run("no audience", func(t *testing.T) {
+		atomic.StoreInt32(&fetches, 0)
+		subject := &eventgrid.BearerTokenAuthenticator{JWKSURL: jwks.URL}
+
+		if err := authenticate(subject, "key1"); err == nil {
+			t.Error("expected every token to be rejected when no audience is configured")
+		}
+		if got := atomic.LoadInt32(&fetches); got != 0 {
+			t.Errorf("got %d fetches of the JWKS document want: 0", got)
+		}
+	})
+}
+
+func TestBearerTokenAuthenticator_StalledJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer jwks.Close()
+	defer close(release)
+
+	const audience = "api://buffalo-azure"
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/events", nil)
+		req.Header.Add("Authorization", "Bearer "+signBearerToken(t, key, "key1", map[string]interface{}{
+			"aud": audience,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}))
+		return req
+	}
+
+	t.Run("request deadline", func(t *testing.T) {
+		subject := &eventgrid.BearerTokenAuthenticator{JWKSURL: jwks.URL, Audience: audience}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				start := time.Now()
+				if err := subject.Authenticate(newRequest().WithContext(ctx)); err == nil {
+					t.Error("expected authentication to fail while the JWKS document can not be fetched")
+				}
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Errorf("waited %v for the JWKS document after the request was done", elapsed)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("fetch timeout", func(t *testing.T) {
+		subject := &eventgrid.BearerTokenAuthenticator{
+			JWKSURL:      jwks.URL,
+			Audience:     audience,
+			FetchTimeout: 50 * time.Millisecond,
+		}
+
+		start := time.Now()
+		if err := subject.Authenticate(newRequest()); err == nil {
+			t.Error("expected authentication to fail while the JWKS document can not be fetched")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("waited %v for the JWKS document, longer than the fetch timeout", elapsed)
+		}
+	})
+}
+
+func TestRegisterSubscriber_WithAuthentication(t *testing.T) {
+	var called bool
+	receiver := eventgrid.SimpleSubscriber{
+		Subscriber: eventgrid.BaseSubscriber{},
+		EventHandler: func(c buffalo.Context, e eventgrid.Event) error {
+			called = true
+			return nil
+		},
+	}
+
+	app := buffalo.New(buffalo.Options{Env: "test"})
+	eventgrid.RegisterSubscriber(app, "/events", receiver, eventgrid.WithAuthentication(eventgrid.SharedSecretAuthenticator{
+		Secret:         "buffalo",
+		QueryParameter: "code",
+	}))
+
+	for _, target := range []string{"/events/", "/events/?code=bison"} {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{}`))
+		req.Header.Add("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		app.ServeHTTP(resp, req)
+
+		if got, want := resp.Code, http.StatusUnauthorized; got != want {
+			t.Errorf("%s: got status: %d want: %d", target, got, want)
+		}
+	}
+
+	if called {
+		t.Error("handler ran for an unauthenticated request")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/events/?code=buffalo", strings.NewReader(fmt.Sprintf(`{"id": %q}`, "1234")))
+	req.Header.Add("Content-Type", "application/json")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Error("handler did not run for an authenticated request")
+	}
+}
+
+func TestRegisterSubscriber_WithAuthentication_List(t *testing.T) {
+	subscriber := eventgrid.NewCachingSubscriber(newOutcomeSubscriber(), nil)
+	receiveStatus(subscriber, NewMockContext(newBatchRequest(t, "Contoso.Succeed")))
+
+	app := buffalo.New(buffalo.Options{Env: "test"})
+	eventgrid.RegisterSubscriber(app, "/events", subscriber, eventgrid.WithAuthentication(eventgrid.SharedSecretAuthenticator{
+		Secret:         "buffalo",
+		QueryParameter: "code",
+	}))
+
+	testCases := []struct {
+		target string
+		want   int
+	}{
+		{"/events/", http.StatusUnauthorized},
+		{"/events/0", http.StatusUnauthorized},
+		{"/events/?code=bison", http.StatusUnauthorized},
+		{"/events/?code=buffalo", http.StatusOK},
+		{"/events/0?code=buffalo", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+		if resp.Code != tc.want {
+			t.Errorf("%s: got status: %d want: %d", tc.target, resp.Code, tc.want)
+		}
+		if resp.Code != http.StatusOK && strings.Contains(resp.Body.String(), "Contoso.Succeed") {
+			t.Errorf("%s: the received events were revealed to an unauthenticated request", tc.target)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// EventResult describes the outcome of processing a single Event in a batch.
+type EventResult struct {
+	// Index is the position of the Event in its batch. Unlike ID, it is unique within a batch.
+	Index int
+
+	ID        string
+	EventType string
+	Status    int
+	Err       error
+
+	// Duration is how long the Event's handler ran for, or was waited on before it was
+	// abandoned.
+	Duration time.Duration
+}
+
+// Succeeded indicates whether or not the Status of this result would be accepted by
+// an Event Grid Topic as meaning the Event should not be delivered again.
+func (r EventResult) Succeeded() bool {
+	_, ok := SuccessStatusCodes()[r.Status]
+	return ok
+}
+
+// eventResult is the serialized form of an EventResult.
+type eventResult struct {
+	ID        string        `json:"id"`
+	EventType string        `json:"eventType"`
+	Status    int           `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+}
+
+// MarshalJSON serializes an EventResult, representing its error as a message.
+func (r EventResult) MarshalJSON() ([]byte, error) {
+	converted := eventResult{
+		ID:        r.ID,
+		EventType: r.EventType,
+		Status:    r.Status,
+		Duration:  r.Duration,
+	}
+	if r.Err != nil {
+		converted.Error = r.Err.Error()
+	}
+	return json.Marshal(converted)
+}
+
+// UnmarshalJSON reads an EventResult that was serialized using MarshalJSON. Its error, if any,
+// is restored as a plain error holding the same message.
+func (r *EventResult) UnmarshalJSON(raw []byte) error {
+	var converted eventResult
+	if err := json.Unmarshal(raw, &converted); err != nil {
+		return err
+	}
+
+	*r = EventResult{
+		ID:        converted.ID,
+		EventType: converted.EventType,
+		Status:    converted.Status,
+		Duration:  converted.Duration,
+	}
+	if converted.Error != "" {
+		r.Err = errors.New(converted.Error)
+	}
+	return nil
+}
+
+// BatchResult holds the outcome of each Event in a batch, in the order the Events appear in
+// the batch.
+type BatchResult []EventResult
+
+// HasFailure indicates whether or not any Event in this batch was not processed successfully.
+func (b BatchResult) HasFailure() bool {
+	for _, r := range b {
+		if !r.Succeeded() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSuccess indicates whether or not any Event in this batch was processed successfully.
+func (b BatchResult) HasSuccess() bool {
+	for _, r := range b {
+		if r.Succeeded() {
+			return true
+		}
+	}
+	return false
+}
+
+// Failures finds the results of each Event that was not processed successfully.
+func (b BatchResult) Failures() (results BatchResult) {
+	for _, r := range b {
+		if !r.Succeeded() {
+			results = append(results, r)
+		}
+	}
+	return
+}
+
+// Get finds the result associated with a particular Event ID. When several Events in the
+// batch share that ID, the result of the first of them is returned.
+func (b BatchResult) Get(id string) (EventResult, bool) {
+	for _, r := range b {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return EventResult{}, false
+}
+
+// At finds the result associated with the Event at a particular position in the batch.
+func (b BatchResult) At(index int) (EventResult, bool) {
+	for _, r := range b {
+		if r.Index == index {
+			return r, true
+		}
+	}
+	return EventResult{}, false
+}
+
+// retryLater indicates whether any Event in this batch asked to be delivered again later,
+// using `RetryLater`.
+func (b BatchResult) retryLater() bool {
+	for _, r := range b {
+		if r.Status == http.StatusServiceUnavailable {
+			return true
+		}
+	}
+	return false
+}
+
+// batchResultKey is the name of the value holding a BatchResult in a `buffalo.Context`.
+const batchResultKey = "eventgrid.batchResult"
+
+// BatchResultFromContext fetches the BatchResult of the most recent batch received by a
+// `TypeDispatchSubscriber` using this Context. This allows `buffalo.MiddlewareFunc`s wrapping
+// the Receive action to inspect the outcome of each Event.
+func BatchResultFromContext(c buffalo.Context) (BatchResult, bool) {
+	result, ok := c.Value(batchResultKey).(BatchResult)
+	return result, ok
+}
+
+// batchEventsKey is the name of the value holding the Events of a batch in a `buffalo.Context`.
+const batchEventsKey = "eventgrid.batchEvents"
+
+// BatchEventsFromContext fetches the Events of the most recent batch received by a
+// `TypeDispatchSubscriber` or `SimpleSubscriber` using this Context, in the order they were
+// delivered. Along with `BatchResultFromContext`, this allows `buffalo.MiddlewareFunc`s
+// wrapping the Receive action to inspect each Event without reading the request again.
+func BatchEventsFromContext(c buffalo.Context) ([]Event, bool) {
+	events, ok := c.Value(batchEventsKey).([]Event)
+	return events, ok
+}
+
+// BatchPolicy decides how a batch of Events is answered, given the outcome of each Event.
+type BatchPolicy int
+
+// These constants define the available `BatchPolicy` values.
+const (
+	// FailWholeBatch answers with an HTTP 500 when any Event in the batch failed. This causes
+	// Event Grid to redeliver the entire batch.
+	FailWholeBatch BatchPolicy = iota
+
+	// SucceedIfAnySucceed answers with an HTTP 200 when at least one Event in the batch was
+	// processed successfully.
+	SucceedIfAnySucceed
+
+	// DeadLetterFailures answers with an HTTP 200 after forwarding each failed Event to a
+	// DeadLetterSink. Should forwarding fail, the whole batch is failed.
+	DeadLetterFailures
+
+	// DeadLetterPermanentFailures answers with an HTTP 200 after forwarding each failed Event
+	// to a DeadLetterSink, so long as the subscriber's ErrorClassifier considers every failure
+	// permanent. Should any failure be worth retrying, the whole batch is failed, and nothing
+	// is forwarded until it is delivered again.
+	DeadLetterPermanentFailures
+)
+
+// status decides which HTTP Status Code should be used to answer a batch.
+func (p BatchPolicy) status(result BatchResult, classifier ErrorClassifier) int {
+	switch p {
+	case SucceedIfAnySucceed:
+		if len(result) == 0 || result.HasSuccess() {
+			return http.StatusOK
+		}
+	case DeadLetterFailures:
+		return http.StatusOK
+	case DeadLetterPermanentFailures:
+		for _, failure := range result.Failures() {
+			if !classifier.Permanent(failure) {
+				return http.StatusInternalServerError
+			}
+		}
+		return http.StatusOK
+	default:
+		if !result.HasFailure() {
+			return http.StatusOK
+		}
+	}
+	return http.StatusInternalServerError
+}
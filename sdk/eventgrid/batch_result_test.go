@@ -0,0 +1,197 @@
+package eventgrid_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+// newBatchRequest creates a request carrying one Event Grid Event for each of the provided
+// Event Types. The ID of each Event is its index in the batch.
+func newBatchRequest(t testing.TB, eventTypes ...string) *http.Request {
+	events := make([]string, 0, len(eventTypes))
+	for i, eventType := range eventTypes {
+		events = append(events, fmt.Sprintf(`{"id": "%d", "eventType": %q, "subject": "/items/%d", "data": {"index": %d}}`, i, eventType, i, i))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader("["+strings.Join(events, ",")+"]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	return req
+}
+
+// receiveStatus hands a request to a Subscriber, and reports the status it answered with.
+func receiveStatus(s eventgrid.Subscriber, ctx *MockContext) int {
+	err := s.Receive(ctx)
+	if httpErr, ok := err.(buffalo.HTTPError); ok {
+		return httpErr.Status
+	}
+	return ctx.Status()
+}
+
+func newOutcomeSubscriber() *eventgrid.TypeDispatchSubscriber {
+	return eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Contoso.Succeed", func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		}).
+		Bind("Contoso.Reject", func(c buffalo.Context, e eventgrid.Event) error {
+			return c.Error(http.StatusBadRequest, errors.New("rejected"))
+		}).
+		Bind("Contoso.Crash", func(c buffalo.Context, e eventgrid.Event) error {
+			return errors.New("crashed")
+		})
+}
+
+func TestTypeDispatchSubscriber_Receive_EventResults(t *testing.T) {
+	subject := newOutcomeSubscriber()
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject", "Contoso.Crash", "Contoso.Unbound"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	result, ok := eventgrid.BatchResultFromContext(ctx)
+	if !ok {
+		t.Fatal("no BatchResult was associated with the Context")
+	}
+
+	want := map[string]int{
+		"0": http.StatusOK,
+		"1": http.StatusBadRequest,
+		"2": http.StatusInternalServerError,
+		"3": http.StatusBadRequest,
+	}
+
+	if got := len(result); got != len(want) {
+		t.Errorf("got %d results want: %d", got, len(want))
+	}
+
+	for id, status := range want {
+		r, ok := result.Get(id)
+		if !ok {
+			t.Errorf("no result for event %q", id)
+			continue
+		}
+		if r.Status != status {
+			t.Errorf("event %q got status: %d want: %d", id, r.Status, status)
+		}
+		if r.Succeeded() == (r.Err != nil) {
+			t.Errorf("event %q has status %d but error %v", id, r.Status, r.Err)
+		}
+	}
+
+	if got, want := len(result.Failures()), 3; got != want {
+		t.Errorf("got %d failures want: %d", got, want)
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_DuplicateIDs(t *testing.T) {
+	const body = `[
+		{"id": "dup", "eventType": "Contoso.Reject", "subject": "/items/0", "data": {}},
+		{"id": "dup", "eventType": "Contoso.Succeed", "subject": "/items/1", "data": {}},
+		{"id": "", "eventType": "Contoso.Crash", "subject": "/items/2", "data": {}},
+		{"id": "", "eventType": "Contoso.Succeed", "subject": "/items/3", "data": {}}
+	]`
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	ctx := NewMockContext(req)
+	receiveStatus(newOutcomeSubscriber(), ctx)
+
+	result, ok := eventgrid.BatchResultFromContext(ctx)
+	if !ok {
+		t.Fatal("no BatchResult was associated with the Context")
+	}
+
+	want := []int{http.StatusBadRequest, http.StatusOK, http.StatusInternalServerError, http.StatusOK}
+	if len(result) != len(want) {
+		t.Fatalf("got %d results want: %d", len(result), len(want))
+	}
+	for i, status := range want {
+		if result[i].Status != status {
+			t.Errorf("event %d got status: %d want: %d", i, result[i].Status, status)
+		}
+		if result[i].Index != i {
+			t.Errorf("event %d got index: %d", i, result[i].Index)
+		}
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_BatchEvents(t *testing.T) {
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject"))
+	receiveStatus(newOutcomeSubscriber(), ctx)
+
+	events, ok := eventgrid.BatchEventsFromContext(ctx)
+	if !ok {
+		t.Fatal("no Events were associated with the Context")
+	}
+	if len(events) != 2 || events[0].ID != "0" || events[0].EventType != "Contoso.Succeed" || events[1].ID != "1" {
+		t.Errorf("got: %+v want the events of the batch in the order they were delivered", events)
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_BatchPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy eventgrid.BatchPolicy
+		types  []string
+		want   int
+	}{
+		{"fail-whole/success", eventgrid.FailWholeBatch, []string{"Contoso.Succeed", "Contoso.Succeed"}, http.StatusOK},
+		{"fail-whole/partial", eventgrid.FailWholeBatch, []string{"Contoso.Succeed", "Contoso.Reject"}, http.StatusInternalServerError},
+		{"succeed-if-any/partial", eventgrid.SucceedIfAnySucceed, []string{"Contoso.Succeed", "Contoso.Reject"}, http.StatusOK},
+		{"succeed-if-any/failure", eventgrid.SucceedIfAnySucceed, []string{"Contoso.Crash", "Contoso.Reject"}, http.StatusInternalServerError},
+		{"dead-letter/partial", eventgrid.DeadLetterFailures, []string{"Contoso.Succeed", "Contoso.Reject", "Contoso.Crash"}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var deadLettered []string
+
+			subject := newOutcomeSubscriber().SetBatchPolicy(tc.policy).SetDeadLetterSink(eventgrid.DeadLetterSinkFunc(func(ctx context.Context, letter eventgrid.DeadLetter) error {
+				lock.Lock()
+				defer lock.Unlock()
+				deadLettered = append(deadLettered, letter.Event.ID)
+				return nil
+			}))
+
+			ctx := NewMockContext(newBatchRequest(t, tc.types...))
+			if got := receiveStatus(subject, ctx); got != tc.want {
+				t.Errorf("got status: %d want: %d", got, tc.want)
+			}
+
+			result, _ := eventgrid.BatchResultFromContext(ctx)
+			wantDeadLettered := 0
+			if tc.policy == eventgrid.DeadLetterFailures {
+				wantDeadLettered = len(result.Failures())
+			}
+			if got := len(deadLettered); got != wantDeadLettered {
+				t.Errorf("got %d dead-lettered events want: %d", got, wantDeadLettered)
+			}
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_DeadLetterFailure(t *testing.T) {
+	subject := newOutcomeSubscriber().SetBatchPolicy(eventgrid.DeadLetterFailures).SetDeadLetterSink(eventgrid.DeadLetterSinkFunc(func(context.Context, eventgrid.DeadLetter) error {
+		return errors.New("disk full")
+	}))
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+}
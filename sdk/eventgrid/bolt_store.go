@@ -0,0 +1,345 @@
+package eventgrid
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltEventsBucket = []byte("eventgrid_events")
+	boltIDsBucket    = []byte("eventgrid_event_ids")
+	boltMetaBucket   = []byte("eventgrid_meta")
+	boltCountKey     = []byte("count")
+)
+
+// BoltStore is a Store which holds Events in a file on local disk, using bbolt. Unlike a
+// `MemoryStore`, the Events it holds survive restarts of an application, but they are not
+// shared between instances of it.
+type BoltStore struct {
+	// DB is the database used to hold Events.
+	DB *bolt.DB
+
+	// TTL is the amount of time each Event is held. When it is not positive, `CacheDefaultTTL`
+	// is used.
+	TTL time.Duration
+
+	// MaxDepth is the largest number of Events that are held. When it is zero,
+	// `CacheDefaultMaxDepth` is used.
+	MaxDepth uint
+}
+
+type boltRecord struct {
+	Event     Event        `json:"event"`
+	Result    *EventResult `json:"result,omitempty"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+}
+
+// OpenBoltStore opens a BoltStore backed by the file at `path`, creating it if it does not
+// already exist. The file may only be opened by one process at a time.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, os.FileMode(0600), &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStore{DB: db}, nil
+}
+
+// Close releases the file backing this store.
+func (s *BoltStore) Close() error {
+	return s.DB.Close()
+}
+
+func (s *BoltStore) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return CacheDefaultTTL
+	}
+	return s.TTL
+}
+
+func (s *BoltStore) maxDepth() uint {
+	if s.MaxDepth == 0 {
+		return CacheDefaultMaxDepth
+	}
+	return s.MaxDepth
+}
+
+// Add records an Event. Events which have expired, or which no longer fit within the
+// MaxDepth of this store, are removed at the same time.
+func (s *BoltStore) Add(_ context.Context, e Event) error {
+	return s.add(e, nil)
+}
+
+// Record records an Event along with the outcome of processing it, in the same way as Add.
+func (s *BoltStore) Record(_ context.Context, e Event, result EventResult) error {
+	return s.add(e, &result)
+}
+
+func (s *BoltStore) add(e Event, result *EventResult) error {
+	now := time.Now()
+
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		b, err := openBoltBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		sequence, err := b.events.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		serialized, err := json.Marshal(boltRecord{Event: e, Result: result, ExpiresAt: now.Add(s.ttl())})
+		if err != nil {
+			return err
+		}
+
+		key := boltKey(sequence)
+		if err = b.events.Put(key, serialized); err != nil {
+			return err
+		}
+		if err = b.ids.Put(boltIDKey(e.ID, key), nil); err != nil {
+			return err
+		}
+		count := b.count() + 1
+
+		// Events are kept in the order they arrived, so the least recently arriving
+		// are found first. They are removed until the rest have not expired, and fit.
+		cursor := b.events.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.First() {
+			var oldest boltRecord
+			if err := json.Unmarshal(v, &oldest); err != nil {
+				return err
+			}
+			if count <= uint64(s.maxDepth()) && oldest.ExpiresAt.After(now) {
+				break
+			}
+
+			if err := b.remove(k, oldest.Event.ID); err != nil {
+				return err
+			}
+			count--
+		}
+		return b.setCount(count)
+	})
+}
+
+// List reads all of the unexpired Events, starting with the most recently arriving.
+func (s *BoltStore) List(_ context.Context) (results []Event, err error) {
+	called := time.Now()
+
+	err = s.DB.View(func(tx *bolt.Tx) error {
+		events := tx.Bucket(boltEventsBucket)
+		if events == nil {
+			return nil
+		}
+
+		cursor := events.Cursor()
+		for k, v := cursor.Last(); k != nil && uint(len(results)) < s.maxDepth(); k, v = cursor.Prev() {
+			var record boltRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if record.ExpiresAt.After(called) {
+				results = append(results, record.Event)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Query reads a page of the unexpired Events which match a `Query`, starting with the most
+// recently arriving.
+func (s *BoltStore) Query(_ context.Context, q Query) (EventPage, error) {
+	page, _, err := s.query(q)
+	return page, err
+}
+
+// QueryReceived reads a page of the unexpired Events which match a `Query`, starting with the
+// most recently arriving, along with the outcome that was recorded for each of them.
+func (s *BoltStore) QueryReceived(_ context.Context, q Query) (ReceivedEventPage, error) {
+	page, results, err := s.query(q)
+	if err != nil {
+		return ReceivedEventPage{}, err
+	}
+	return receivedPage(page, results), nil
+}
+
+func (s *BoltStore) query(q Query) (EventPage, []*EventResult, error) {
+	if err := q.validate(); err != nil {
+		return EventPage{}, nil, err
+	}
+	start, _ := q.position()
+
+	called := time.Now()
+	page := newPager(q)
+
+	var results []*EventResult
+
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		events := tx.Bucket(boltEventsBucket)
+		if events == nil {
+			return nil
+		}
+
+		cursor := events.Cursor()
+		k, v := cursor.Last()
+		if start != 0 {
+			// Seek finds the first key at or after the cursor, so the page starts before it.
+			if k, _ = cursor.Seek(boltKey(start)); k == nil {
+				k, v = cursor.Last()
+			} else {
+				k, v = cursor.Prev()
+			}
+		}
+
+		for ; k != nil; k, v = cursor.Prev() {
+			var record boltRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if !record.ExpiresAt.After(called) || !q.matches(record.Event) {
+				continue
+			}
+			if !page.add(record.Event, binary.BigEndian.Uint64(k)) {
+				break
+			}
+			results = append(results, record.Result)
+		}
+		return nil
+	})
+	return page.page, results, err
+}
+
+// Get fetches the most recently arriving, unexpired Event with a particular ID.
+func (s *BoltStore) Get(ctx context.Context, id string) (Event, bool, error) {
+	found, ok, err := s.GetReceived(ctx, id)
+	return found.Event, ok, err
+}
+
+// GetReceived fetches the most recently arriving, unexpired Event with a particular ID, along
+// with the outcome that was recorded for it.
+func (s *BoltStore) GetReceived(_ context.Context, id string) (found ReceivedEvent, ok bool, err error) {
+	called := time.Now()
+
+	err = s.DB.View(func(tx *bolt.Tx) error {
+		events, ids := tx.Bucket(boltEventsBucket), tx.Bucket(boltIDsBucket)
+		if events == nil || ids == nil {
+			return nil
+		}
+
+		for _, key := range boltKeysFor(ids, id) {
+			var record boltRecord
+			if err := json.Unmarshal(events.Get(key), &record); err != nil {
+				return err
+			}
+			if record.ExpiresAt.After(called) {
+				found, ok = ReceivedEvent{Event: record.Event, Result: record.Result}, true
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Delete removes every Event with a particular ID, and reports whether there were any.
+func (s *BoltStore) Delete(_ context.Context, id string) (found bool, err error) {
+	err = s.DB.Update(func(tx *bolt.Tx) error {
+		b, err := openBoltBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		keys := boltKeysFor(b.ids, id)
+		for _, key := range keys {
+			if err := b.remove(key, id); err != nil {
+				return err
+			}
+		}
+		found = len(keys) > 0
+		return b.setCount(b.count() - uint64(len(keys)))
+	})
+	return
+}
+
+// Clear removes every Event.
+func (s *BoltStore) Clear(_ context.Context) error {
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltEventsBucket, boltIDsBucket, boltMetaBucket} {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// boltBuckets are the buckets used by a BoltStore, within a single transaction.
+type boltBuckets struct {
+	events *bolt.Bucket // of boltRecord, by the order the Events arrived
+	ids    *bolt.Bucket // of nothing, by the ID of each Event followed by its key in events
+	meta   *bolt.Bucket
+}
+
+func openBoltBuckets(tx *bolt.Tx) (b boltBuckets, err error) {
+	if b.events, err = tx.CreateBucketIfNotExists(boltEventsBucket); err != nil {
+		return
+	}
+	if b.ids, err = tx.CreateBucketIfNotExists(boltIDsBucket); err != nil {
+		return
+	}
+	b.meta, err = tx.CreateBucketIfNotExists(boltMetaBucket)
+	return
+}
+
+// count fetches the number of Events held, which is tracked separately because bbolt can
+// only count the keys in a bucket by visiting all of them.
+func (b boltBuckets) count() uint64 {
+	if raw := b.meta.Get(boltCountKey); len(raw) == 8 {
+		return binary.BigEndian.Uint64(raw)
+	}
+	return 0
+}
+
+func (b boltBuckets) setCount(count uint64) error {
+	return b.meta.Put(boltCountKey, boltKey(count))
+}
+
+// remove deletes a single Event, without updating the count of Events held.
+func (b boltBuckets) remove(key []byte, id string) error {
+	if err := b.events.Delete(key); err != nil {
+		return err
+	}
+	return b.ids.Delete(boltIDKey(id, key))
+}
+
+// boltKey creates a key which sorts in the order that Events arrived.
+func boltKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
+
+// boltIDKey creates a key in the index of Event IDs, which refers to the key of an Event.
+func boltIDKey(id string, key []byte) []byte {
+	return append(append([]byte(id), 0), key...)
+}
+
+// boltKeysFor finds the keys of every Event with a particular ID, in the order they arrived.
+func boltKeysFor(ids *bolt.Bucket, id string) (keys [][]byte) {
+	prefix := append([]byte(id), 0)
+
+	cursor := ids.Cursor()
+	for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+		if len(k) == len(prefix)+8 {
+			keys = append(keys, append([]byte(nil), k[len(prefix):]...))
+		}
+	}
+	return
+}
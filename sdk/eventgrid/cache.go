@@ -1,6 +1,8 @@
 package eventgrid
 
 import (
+	"container/list"
+	"context"
 	"sync"
 	"time"
 )
@@ -10,15 +12,110 @@ import (
 const CacheDefaultMaxDepth uint = 100000
 
 // CacheDefaultTTL is the default length of time that each event will live
-// in the cache before it is aut
+// in the cache before it is automatically removed.
 const CacheDefaultTTL = time.Hour * 48
 
+// CacheDefaultJanitorInterval is how often a Cache created with `NewCache` removes the
+// Events which have expired.
+const CacheDefaultJanitorInterval = time.Minute
+
 // Cache will hold a set number of events for a short amount of time.
+//
+// Events are kept in the order they arrived, and indexed by their ID, so that adding,
+// fetching, and removing an Event each take constant time. Once there are more than
+// `MaxDepth` Events, the least recently arriving ones are removed. Expired Events are never
+// returned, and are removed as new Events are added, or by a background janitor when one has
+// been started.
+//
+// The zero value is ready to use, and does not start a janitor.
 type Cache struct {
 	sync.RWMutex
 	maxDepth uint
 	ttl      time.Duration
-	root     *cacheNode
+	entries  *list.List // of *cacheEntry, most recently arriving first
+	index    map[string][]*list.Element
+	position uint64
+	stop     context.CancelFunc
+	stopped  chan struct{}
+}
+
+type cacheEntry struct {
+	Event
+	result     *EventResult
+	expiration time.Time
+	position   uint64
+}
+
+// ReceivedEvent is an Event held by a `Cache` or `Store`, along with the outcome of processing
+// it when one was recorded using `Cache.Record` or `Store.Record`.
+type ReceivedEvent struct {
+	Event  Event        `json:"event"`
+	Result *EventResult `json:"result,omitempty"`
+}
+
+// ReceivedEventPage is a group of Events held by a `Cache` or `Store` that match a `Query`,
+// along with the outcome of processing each of them.
+type ReceivedEventPage struct {
+	Events []ReceivedEvent `json:"events"`
+
+	// NextCursor should be used as the Cursor of a `Query` in order to fetch the next page.
+	// It is empty when there are no more matching Events.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// NewCache creates a Cache which removes expired Events every `CacheDefaultJanitorInterval`,
+// until the provided Context is done or the Cache is closed.
+func NewCache(ctx context.Context) *Cache {
+	created := &Cache{}
+	created.StartJanitor(ctx, CacheDefaultJanitorInterval)
+	return created
+}
+
+// StartJanitor starts a goroutine which removes expired Events every `interval`, until the
+// provided Context is done or `Close` is called. Should a janitor already be running, it is
+// stopped first.
+func (c *Cache) StartJanitor(ctx context.Context, interval time.Duration) {
+	c.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+
+	c.Lock()
+	c.stop, c.stopped = cancel, stopped
+	c.Unlock()
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				c.Lock()
+				c.removeExpired(now)
+				c.Unlock()
+			}
+		}
+	}()
+}
+
+// Close stops the janitor of this Cache, if one is running, and waits for it to exit. The
+// Events in the Cache are still available afterwards.
+func (c *Cache) Close() error {
+	c.Lock()
+	stop, stopped := c.stop, c.stopped
+	c.stop, c.stopped = nil, nil
+	c.Unlock()
+
+	if stop != nil {
+		stop()
+		<-stopped
+	}
+	return nil
 }
 
 // MaxDepth gets the largest number of `Event` instances that this `Cache`
@@ -38,12 +135,14 @@ func (c *Cache) _MaxDepth() uint {
 }
 
 // SetMaxDepth changes the largest number of `Event` instances that this `Cache`.
-// will hold.
+// will hold. Should it already hold more than that, the least recently arriving
+// ones are removed.
 func (c *Cache) SetMaxDepth(depth uint) {
 	c.Lock()
 	defer c.Unlock()
 
 	c.maxDepth = depth
+	c.trim()
 }
 
 // TTL get the amount of time each event will last before being cleared from the `Cache`.
@@ -62,6 +161,7 @@ func (c *Cache) _TTL() time.Duration {
 }
 
 // SetTTL sets the amount of time each event will last before being cleared from the `Cache`.
+// Events which are already in the `Cache` keep the expiration they were given when added.
 func (c *Cache) SetTTL(d time.Duration) {
 	c.Lock()
 	defer c.Unlock()
@@ -71,16 +171,74 @@ func (c *Cache) SetTTL(d time.Duration) {
 
 // Add creates an entry in the `Cache`.
 func (c *Cache) Add(e Event) {
+	c.add(e, nil)
+}
+
+// Record creates an entry in the `Cache` which holds the outcome of processing an Event,
+// alongside the Event itself.
+func (c *Cache) Record(e Event, result EventResult) {
+	c.add(e, &result)
+}
+
+func (c *Cache) add(e Event, result *EventResult) {
 	c.Lock()
 	defer c.Unlock()
 
-	created := &cacheNode{
+	if c.entries == nil {
+		c.entries = list.New()
+		c.index = make(map[string][]*list.Element)
+	}
+
+	now := time.Now()
+	c.removeExpired(now)
+
+	c.position++
+	created := c.entries.PushFront(&cacheEntry{
 		Event:      e,
-		next:       c.root,
-		expiration: time.Now().Add(c._TTL()),
+		result:     result,
+		expiration: now.Add(c._TTL()),
+		position:   c.position,
+	})
+	c.index[e.ID] = append(c.index[e.ID], created)
+
+	c.trim()
+}
+
+// Get fetches the most recently arriving, unexpired Event with a particular ID.
+func (c *Cache) Get(id string) (Event, bool) {
+	found, ok := c.GetReceived(id)
+	return found.Event, ok
+}
+
+// GetReceived fetches the most recently arriving, unexpired Event with a particular ID, along
+// with the outcome that was recorded for it.
+func (c *Cache) GetReceived(id string) (ReceivedEvent, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	called := time.Now()
+
+	matches := c.index[id]
+	for i := len(matches) - 1; i >= 0; i-- {
+		if entry := matches[i].Value.(*cacheEntry); entry.expiration.After(called) {
+			return entry.received(), true
+		}
 	}
+	return ReceivedEvent{}, false
+}
+
+// Delete removes every Event with a particular ID from the `Cache`, and reports whether
+// there were any.
+func (c *Cache) Delete(id string) bool {
+	c.Lock()
+	defer c.Unlock()
 
-	c.root = created
+	matches, ok := c.index[id]
+	for _, element := range matches {
+		c.entries.Remove(element)
+	}
+	delete(c.index, id)
+	return ok
 }
 
 // Clear removes all entries from the Event Cache.
@@ -88,37 +246,150 @@ func (c *Cache) Clear() {
 	c.Lock()
 	defer c.Unlock()
 
-	c.root = nil
+	c.entries, c.index = nil, nil
 }
 
-// List reads all of the Events in the cache at a particular moment.
+// List reads all of the Events in the cache at a particular moment, starting with the most
+// recently arriving.
 func (c *Cache) List() (results []Event) {
 	c.RLock()
 	defer c.RUnlock()
 
+	if c.entries == nil {
+		return
+	}
+
+	called := time.Now()
+
+	results = make([]Event, 0, c.entries.Len())
+	for current := c.entries.Front(); current != nil; current = current.Next() {
+		if entry := current.Value.(*cacheEntry); entry.expiration.After(called) {
+			results = append(results, entry.Event)
+		}
+	}
+	return
+}
+
+// Query reads a page of the unexpired Events which match a `Query`, starting with the most
+// recently arriving.
+func (c *Cache) Query(q Query) (EventPage, error) {
+	page, _, err := c.query(q)
+	return page, err
+}
+
+// QueryReceived reads a page of the unexpired Events which match a `Query`, starting with the
+// most recently arriving, along with the outcome that was recorded for each of them.
+func (c *Cache) QueryReceived(q Query) (ReceivedEventPage, error) {
+	page, results, err := c.query(q)
+	if err != nil {
+		return ReceivedEventPage{}, err
+	}
+
+	return receivedPage(page, results), nil
+}
+
+// receivedPage pairs each Event on a page with the outcome recorded for it, which is found at
+// the same position in results.
+func receivedPage(page EventPage, results []*EventResult) ReceivedEventPage {
+	received := ReceivedEventPage{
+		Events:     make([]ReceivedEvent, 0, len(page.Events)),
+		NextCursor: page.NextCursor,
+	}
+	for i, e := range page.Events {
+		entry := cacheEntry{Event: e, result: results[i]}
+		received.Events = append(received.Events, entry.received())
+	}
+	return received
+}
+
+func (c *Cache) query(q Query) (EventPage, []*EventResult, error) {
+	if err := q.validate(); err != nil {
+		return EventPage{}, nil, err
+	}
+	start, _ := q.position()
+
+	c.RLock()
+	defer c.RUnlock()
+
 	called := time.Now()
+	page := newPager(q)
 
-	prev := c.root
-	i := uint(0)
+	if c.entries == nil {
+		return page.page, nil, nil
+	}
 
-	for current := c.root; current != nil; current = current.next {
-		if i >= c._MaxDepth() {
-			current.next = nil
+	var results []*EventResult
+	for current := c.entries.Front(); current != nil; current = current.Next() {
+		entry := current.Value.(*cacheEntry)
+		if start != 0 && entry.position >= start {
+			continue
+		}
+		if !entry.expiration.After(called) || !q.matches(entry.Event) {
+			continue
+		}
+		if !page.add(entry.Event, entry.position) {
 			break
 		}
+		results = append(results, entry.result)
+	}
+	return page.page, results, nil
+}
+
+// trim removes the least recently arriving Events until there are no more than `MaxDepth`.
+// It must be called while holding the write lock.
+func (c *Cache) trim() {
+	if c.entries == nil {
+		return
+	}
+
+	for uint(c.entries.Len()) > c._MaxDepth() {
+		c.remove(c.entries.Back())
+	}
+}
+
+// removeExpired removes Events which expired before `now`. Because each Event is added with
+// the current TTL, the least recently arriving are usually the first to expire, so it only
+// looks until it finds one which has not. Any others are skipped by readers until they are
+// reached. It must be called while holding the write lock.
+func (c *Cache) removeExpired(now time.Time) {
+	if c.entries == nil {
+		return
+	}
 
-		if current.expiration.After(called) {
-			results = append(results, current.Event)
-			i++
-		} else {
-			prev.next = current.next
+	for oldest := c.entries.Back(); oldest != nil; oldest = c.entries.Back() {
+		if oldest.Value.(*cacheEntry).expiration.After(now) {
+			return
 		}
+		c.remove(oldest)
 	}
-	return
 }
 
-type cacheNode struct {
-	Event
-	next       *cacheNode
-	expiration time.Time
+// received copies an entry, so that the outcome held by the Cache can not be modified.
+func (entry *cacheEntry) received() ReceivedEvent {
+	created := ReceivedEvent{Event: entry.Event}
+	if entry.result != nil {
+		result := *entry.result
+		created.Result = &result
+	}
+	return created
+}
+
+// remove takes a single Event out of both the list and the index. It must be called while
+// holding the write lock.
+func (c *Cache) remove(element *list.Element) {
+	id := c.entries.Remove(element).(*cacheEntry).ID
+
+	matches := c.index[id]
+	for i, match := range matches {
+		if match == element {
+			matches = append(matches[:i], matches[i+1:]...)
+			break
+		}
+	}
+
+	if len(matches) == 0 {
+		delete(c.index, id)
+	} else {
+		c.index[id] = matches
+	}
 }
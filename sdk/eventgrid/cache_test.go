@@ -2,13 +2,19 @@ package eventgrid_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/Azure/buffalo-azure/sdk/eventgrid"
 )
 
+// Cache embeds a sync.RWMutex, which callers may use to coordinate with it.
+var _ sync.Locker = &eventgrid.Cache{}
+
 func ExampleCache() {
 	myCache := &eventgrid.Cache{}
 
@@ -24,7 +30,7 @@ func ExampleCache() {
 	myCache.Clear()
 	fmt.Println(myCache.List())
 	// Output:
-	// [{   [] Microsoft.Storage.BlobCreated   } {   [] Contoso.Buffalo.CacheProd   }]
+	// [{   [] Microsoft.Storage.BlobCreated    } {   [] Contoso.Buffalo.CacheProd    }]
 	// []
 }
 
@@ -87,3 +93,198 @@ func TestCache_SetMaxDepth(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func ExampleCache_Get() {
+	myCache := &eventgrid.Cache{}
+
+	myCache.Add(eventgrid.Event{
+		ID:        "2d1781af-3a4c-4d7c-bd0c-e34b19da4e66",
+		EventType: "Microsoft.Storage.BlobCreated",
+	})
+
+	found, ok := myCache.Get("2d1781af-3a4c-4d7c-bd0c-e34b19da4e66")
+	fmt.Println(found.EventType, ok)
+
+	myCache.Delete("2d1781af-3a4c-4d7c-bd0c-e34b19da4e66")
+	_, ok = myCache.Get("2d1781af-3a4c-4d7c-bd0c-e34b19da4e66")
+	fmt.Println(ok)
+
+	// Output:
+	// Microsoft.Storage.BlobCreated true
+	// false
+}
+
+func TestCache_Get(t *testing.T) {
+	myCache := &eventgrid.Cache{}
+	myCache.SetMaxDepth(3)
+
+	if _, ok := myCache.Get("a"); ok {
+		t.Error("found an event in an empty cache")
+	}
+
+	myCache.Add(eventgrid.Event{ID: "a", Subject: "first"})
+	myCache.Add(eventgrid.Event{ID: "b"})
+	myCache.Add(eventgrid.Event{ID: "a", Subject: "second"})
+
+	if got, ok := myCache.Get("a"); !ok || got.Subject != "second" {
+		t.Errorf("got: %q, %v want the most recently arriving event", got.Subject, ok)
+	}
+
+	myCache.Add(eventgrid.Event{ID: "c"})
+	myCache.Add(eventgrid.Event{ID: "d"})
+
+	if _, ok := myCache.Get("b"); ok {
+		t.Error("found an event which should have been evicted")
+	}
+	if got, ok := myCache.Get("a"); !ok || got.Subject != "second" {
+		t.Errorf("got: %q, %v want: %q", got.Subject, ok, "second")
+	}
+
+	myCache.SetMaxDepth(1)
+	if _, ok := myCache.Get("a"); ok {
+		t.Error("found an event which should have been evicted when the max depth was reduced")
+	}
+	if got := len(myCache.List()); got != 1 {
+		t.Errorf("got length: %d want: 1", got)
+	}
+}
+
+func TestCache_Delete(t *testing.T) {
+	myCache := &eventgrid.Cache{}
+
+	if myCache.Delete("a") {
+		t.Error("deleted an event from an empty cache")
+	}
+
+	myCache.Add(eventgrid.Event{ID: "a"})
+	myCache.Add(eventgrid.Event{ID: "b"})
+	myCache.Add(eventgrid.Event{ID: "a"})
+
+	if !myCache.Delete("a") {
+		t.Error("expected events to be deleted")
+	}
+	if myCache.Delete("a") {
+		t.Error("deleted events twice")
+	}
+
+	list := myCache.List()
+	if len(list) != 1 || list[0].ID != "b" {
+		t.Errorf("got: %v want only event %q", list, "b")
+	}
+}
+
+func TestCache_StartJanitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	myCache := &eventgrid.Cache{}
+	myCache.SetTTL(10 * time.Millisecond)
+	myCache.StartJanitor(ctx, time.Millisecond)
+	defer myCache.Close()
+
+	myCache.Add(eventgrid.Event{ID: "a"})
+	if _, ok := myCache.Get("a"); !ok {
+		t.Fatal("event was not found before it expired")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(myCache.List()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired event was never removed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, ok := myCache.Get("a"); ok {
+		t.Error("found an expired event")
+	}
+}
+
+func TestCache_Close(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	myCache := eventgrid.NewCache(ctx)
+	cancel()
+
+	myCache.Add(eventgrid.Event{ID: "a"})
+
+	for i := 0; i < 2; i++ {
+		if err := myCache.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, ok := myCache.Get("a"); !ok {
+		t.Error("events should still be available after the cache is closed")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	myCache := &eventgrid.Cache{}
+	myCache.SetMaxDepth(50)
+	myCache.SetTTL(5 * time.Millisecond)
+	myCache.StartJanitor(ctx, time.Millisecond)
+	defer myCache.Close()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				id := strconv.Itoa(i % 20)
+				switch (worker + i) % 4 {
+				case 0:
+					myCache.Add(eventgrid.Event{ID: id})
+				case 1:
+					myCache.Get(id)
+				case 2:
+					myCache.List()
+				case 3:
+					myCache.Delete(id)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if got := len(myCache.List()); got > 50 {
+		t.Errorf("got length: %d want no more than: 50", got)
+	}
+}
+
+func BenchmarkCache_Add(b *testing.B) {
+	myCache := &eventgrid.Cache{}
+	myCache.SetMaxDepth(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		myCache.Add(eventgrid.Event{ID: strconv.Itoa(i)})
+	}
+}
+
+func BenchmarkCache_Get(b *testing.B) {
+	myCache := &eventgrid.Cache{}
+	for i := 0; i < 1000; i++ {
+		myCache.Add(eventgrid.Event{ID: strconv.Itoa(i)})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		myCache.Get(strconv.Itoa(i % 1000))
+	}
+}
+
+func BenchmarkCache_List(b *testing.B) {
+	myCache := &eventgrid.Cache{}
+	for i := 0; i < 1000; i++ {
+		myCache.Add(eventgrid.Event{ID: strconv.Itoa(i)})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		myCache.List()
+	}
+}
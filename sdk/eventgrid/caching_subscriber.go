@@ -0,0 +1,172 @@
+package eventgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pkg/errors"
+)
+
+// CachingSubscriberDefaultMaxDepth is the largest number of Events held by the Store that
+// `NewCachingSubscriber` creates when none is provided.
+const CachingSubscriberDefaultMaxDepth uint = 100
+
+// CachingSubscriberDefaultTTL is the amount of time each Event is held by the Store that
+// `NewCachingSubscriber` creates when none is provided.
+const CachingSubscriberDefaultTTL = time.Hour
+
+// CachingSubscriber decorates another `Subscriber`, recording each Event it receives in a
+// `Store` along with the outcome of processing it. The List and Show actions are answered
+// from that Store, so that recently received Events can be inspected, while New is handled
+// by the decorated Subscriber.
+//
+// The Events, and the outcome of each, are read from the Context once the decorated
+// Subscriber has processed them, using `BatchEventsFromContext` and `BatchResultFromContext`,
+// so the request is only read once. A `TypeDispatchSubscriber` or `SimpleSubscriber` leaves
+// both of them behind. Should there be no BatchResult, every Event in the request is given
+// the outcome of the request as a whole.
+//
+// The List and Show actions reveal the data of every Event that was received. Routes created
+// by `RegisterSubscriber` authenticate them in the same way as Receive, when
+// `WithAuthentication` is used.
+type CachingSubscriber struct {
+	Subscriber
+	Store Store
+}
+
+// NewCachingSubscriber creates a CachingSubscriber which records the Events received by parent
+// in the provided Store. Should store be nil, a `MemoryStore` is created which holds up to
+// `CachingSubscriberDefaultMaxDepth` Events for `CachingSubscriberDefaultTTL`, and removes
+// expired Events every `CacheDefaultJanitorInterval`.
+func NewCachingSubscriber(parent Subscriber, store Store) *CachingSubscriber {
+	if parent == nil {
+		panic("parent Subscriber must not be nil")
+	}
+
+	if store == nil {
+		created := NewMemoryStore(CachingSubscriberDefaultTTL, CachingSubscriberDefaultMaxDepth)
+		created.StartJanitor(context.Background(), CacheDefaultJanitorInterval)
+		store = created
+	}
+
+	return &CachingSubscriber{
+		Subscriber: parent,
+		Store:      store,
+	}
+}
+
+// Receive hands the request to the decorated Subscriber, then records each Event that was
+// delivered by it, along with the outcome of processing that Event. Requests which did not
+// hold readable Events are not recorded. Should an Event fail to be recorded, the failure is
+// logged, and the outcome of the request is unaffected.
+func (s *CachingSubscriber) Receive(c buffalo.Context) error {
+	started := time.Now()
+	err := s.Subscriber.Receive(c)
+	elapsed := time.Since(started)
+
+	events, ok := BatchEventsFromContext(c)
+	if !ok {
+		return err
+	}
+
+	results, _ := BatchResultFromContext(c)
+	for i, e := range events {
+		result, ok := results.At(i)
+		if !ok {
+			result = requestResult(i, e, err, elapsed)
+		}
+		if recordErr := s.Store.Record(c, e, result); recordErr != nil {
+			if logger := c.Logger(); logger != nil {
+				logger.Error(errors.Wrapf(recordErr, "unable to record event %q", e.ID))
+			}
+		}
+	}
+
+	return err
+}
+
+// requestResult describes the outcome of the Event at a particular position in its batch
+// using the outcome of the request which delivered it.
+func requestResult(index int, e Event, err error, elapsed time.Duration) EventResult {
+	return EventResult{
+		Index:     index,
+		ID:        e.ID,
+		EventType: e.EventType,
+		Status:    errorStatus(err),
+		Err:       err,
+		Duration:  elapsed,
+	}
+}
+
+// errorStatus finds the HTTP Status Code that a request would be answered with, given the
+// error returned by the Handler which processed it. A `buffalo.HTTPError` is found even when
+// it has been wrapped.
+func errorStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	for wrapped := err; wrapped != nil; wrapped = unwrap(wrapped) {
+		if httpErr, ok := wrapped.(buffalo.HTTPError); ok {
+			return httpErr.Status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// List responds with a page of the Events that were recently received, along with the
+// outcome of processing each of them. The Events are filtered using the query parameters of
+// the request, as they are read by `ParseEventQuery`. When the "Accept" header of the request
+// prefers HTML, a table of the Events is rendered, otherwise the `ReceivedEventPage` is
+// written as JSON.
+func (s *CachingSubscriber) List(c buffalo.Context) error {
+	q, err := ParseEventQuery(c.Request().URL.Query())
+	if err != nil {
+		return c.Error(http.StatusBadRequest, err)
+	}
+
+	page, err := s.Store.QueryReceived(c, q)
+	if err != nil {
+		return c.Error(http.StatusInternalServerError, err)
+	}
+
+	return renderEvents(c, q, page, page.Events, page.NextCursor, true)
+}
+
+// New shows the development console of the decorated Subscriber, when it has one, so that
+// the Events it sends are recorded along with the others. See `TypeDispatchSubscriber.New`.
+func (s *CachingSubscriber) New(c buffalo.Context) error {
+	return s.console(c, s.Receive)
+}
+
+func (s *CachingSubscriber) console(c buffalo.Context, receive buffalo.Handler) error {
+	if console, ok := s.Subscriber.(eventConsole); ok {
+		return console.console(c, receive)
+	}
+	return s.Subscriber.New(c)
+}
+
+// Show responds with the most recently received Event identified by the "event_id"
+// parameter, along with the outcome of processing it, as JSON. Should there be no such Event,
+// an HTTP 404 Status Code is returned.
+func (s *CachingSubscriber) Show(c buffalo.Context) error {
+	id := c.Param("event_id")
+
+	found, ok, err := s.Store.GetReceived(c, id)
+	if err != nil {
+		return c.Error(http.StatusInternalServerError, err)
+	}
+	if !ok {
+		return c.Error(http.StatusNotFound, fmt.Errorf("no event found with id %q", id))
+	}
+
+	c.Response().Header().Set("Content-Type", "application/json")
+	c.Response().WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(c.Response()).Encode(found); err != nil {
+		return c.Error(http.StatusInternalServerError, err)
+	}
+	return nil
+}
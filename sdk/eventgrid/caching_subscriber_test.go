@@ -0,0 +1,163 @@
+package eventgrid_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func TestCachingSubscriber_Receive(t *testing.T) {
+	subject := eventgrid.NewCachingSubscriber(newOutcomeSubscriber(), nil)
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject", "Contoso.Crash"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	want := map[string]int{
+		"0": http.StatusOK,
+		"1": http.StatusBadRequest,
+		"2": http.StatusInternalServerError,
+	}
+
+	for id, status := range want {
+		found, ok, err := subject.Store.GetReceived(context.Background(), id)
+		if err != nil || !ok {
+			t.Errorf("event %q was not recorded", id)
+			continue
+		}
+		if found.Event.ID != id || found.Event.Subject == "" || len(found.Event.Data) == 0 {
+			t.Errorf("event %q was not faithfully recorded, got: %+v", id, found.Event)
+		}
+		if found.Result == nil {
+			t.Errorf("no outcome was recorded for event %q", id)
+			continue
+		}
+		if found.Result.Status != status {
+			t.Errorf("event %q got status: %d want: %d", id, found.Result.Status, status)
+		}
+		if (found.Result.Err != nil) == (status == http.StatusOK) {
+			t.Errorf("event %q got error: %v", id, found.Result.Err)
+		}
+		if found.Result.Duration <= 0 {
+			t.Errorf("event %q got duration: %v want one to be recorded", id, found.Result.Duration)
+		}
+	}
+}
+
+func TestCachingSubscriber_Receive_WithoutBatchResult(t *testing.T) {
+	rejected := eventgrid.SimpleSubscriber{
+		Subscriber: eventgrid.BaseSubscriber{},
+		EventHandler: func(c buffalo.Context, e eventgrid.Event) error {
+			return c.Error(http.StatusForbidden, errors.New("forbidden"))
+		},
+	}
+	subject := eventgrid.NewCachingSubscriber(rejected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id": "a", "eventType": "Contoso.A"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := subject.Receive(NewMockContext(req)); err == nil {
+		t.Error("expected the error of the decorated Subscriber to be returned")
+	}
+
+	found, ok, err := subject.Store.GetReceived(context.Background(), "a")
+	if err != nil || !ok || found.Result == nil {
+		t.Fatalf("got: %+v, %v want the event and its outcome to be recorded", found, ok)
+	}
+	if found.Result.Status != http.StatusForbidden {
+		t.Errorf("got status: %d want: %d", found.Result.Status, http.StatusForbidden)
+	}
+}
+
+func TestCachingSubscriber_Receive_Unreadable(t *testing.T) {
+	subject := eventgrid.NewCachingSubscriber(newOutcomeSubscriber(), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`not json`))
+	req.Header.Set("Content-Type", "application/json")
+
+	if got, want := receiveStatus(subject, NewMockContext(req)), http.StatusBadRequest; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	if got, _ := subject.Store.List(context.Background()); len(got) != 0 {
+		t.Errorf("got: %+v want nothing to be recorded", got)
+	}
+}
+
+func TestCachingSubscriber_List(t *testing.T) {
+	subject := eventgrid.NewCachingSubscriber(newOutcomeSubscriber(), nil)
+	receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject", "Contoso.Succeed")))
+
+	t.Run("JSON", func(t *testing.T) {
+		ctx := NewMockContext(httptest.NewRequest(http.MethodGet, "/?event_type=contoso.succeed", nil))
+		if err := subject.List(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		var page eventgrid.ReceivedEventPage
+		if err := json.NewDecoder(ctx.Body()).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(page.Events) != 2 {
+			t.Fatalf("got: %+v want two events", page)
+		}
+		for _, received := range page.Events {
+			if received.Event.EventType != "Contoso.Succeed" || received.Result == nil || received.Result.Status != http.StatusOK {
+				t.Errorf("got: %+v", received)
+			}
+		}
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?event_type=contoso.reject", nil)
+		req.Header.Set("Accept", "text/html")
+
+		ctx := NewMockContext(req)
+		if err := subject.List(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := ioutil.ReadAll(ctx.Body())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"<th>Status</th>", "<td>400</td>", "rejected"} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("expected the page to contain %q, got:\n%s", want, body)
+			}
+		}
+	})
+}
+
+func TestCachingSubscriber_Show(t *testing.T) {
+	subject := eventgrid.NewCachingSubscriber(newOutcomeSubscriber(), nil)
+	receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject")))
+
+	ctx := NewMockContext(httptest.NewRequest(http.MethodGet, "/?event_id=1", nil))
+	if err := subject.Show(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var found eventgrid.ReceivedEvent
+	if err := json.NewDecoder(ctx.Body()).Decode(&found); err != nil {
+		t.Fatal(err)
+	}
+	if found.Event.ID != "1" || found.Result == nil || found.Result.Status != http.StatusBadRequest || found.Result.Err == nil {
+		t.Errorf("got: %+v", found)
+	}
+
+	missing := NewMockContext(httptest.NewRequest(http.MethodGet, "/?event_id=missing", nil))
+	err := subject.Show(missing)
+	if httpErr, ok := err.(buffalo.HTTPError); !ok || httpErr.Status != http.StatusNotFound {
+		t.Errorf("got: %v want an HTTP 404", err)
+	}
+}
@@ -0,0 +1,212 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// CloudEventsSpecVersion is the version of the CloudEvents specification that
+// is understood by this package.
+const CloudEventsSpecVersion = "1.0"
+
+// CloudEventsContentType is the media type used to deliver a single CloudEvent
+// in structured content mode.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEventsBatchContentType is the media type used to deliver several CloudEvents
+// in batched content mode.
+const CloudEventsBatchContentType = "application/cloudevents-batch+json"
+
+// cloudEventsHeaderPrefix is prepended to each attribute name when a CloudEvent
+// is delivered in binary content mode.
+const cloudEventsHeaderPrefix = "Ce-"
+
+// CloudEvent allows for easy processing of events that were published using the
+// CloudEvents v1.0 schema.
+//
+// External documentation on the CloudEvents schema in Event Grid can be found here:
+// https://docs.microsoft.com/en-us/azure/event-grid/cloud-event-schema
+type CloudEvent struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	DataVersion     string          `json:"dataversion,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      string          `json:"data_base64,omitempty"`
+}
+
+// Event translates a CloudEvent into the Event Grid schema, so that it can be
+// handed to an `EventHandler`. The CloudEvents "type" attribute becomes the
+// `EventType`, and "source" becomes the `Topic`. The CloudEvents schema has no
+// equivalent of `DataVersion`, so it is only filled from the "dataversion"
+// extension attribute, when one was sent. "dataschema" is kept as `DataSchema`.
+//
+// Data that was not JSON to begin with is represented as a base64 encoded JSON
+// string, which can be recovered by calling `UnmarshalData` with a `*[]byte`.
+func (ce CloudEvent) Event() Event {
+	data := ce.Data
+	if ce.DataBase64 != "" {
+		// A base64 string is already valid JSON once it is quoted.
+		data = json.RawMessage(fmt.Sprintf("%q", ce.DataBase64))
+	}
+
+	return Event{
+		ID:          ce.ID,
+		Topic:       ce.Source,
+		Subject:     ce.Subject,
+		Data:        data,
+		EventType:   ce.Type,
+		EventTime:   ce.Time,
+		DataVersion: ce.DataVersion,
+		DataSchema:  ce.DataSchema,
+	}
+}
+
+// IsCloudEventsRequest determines whether or not a request carries events that
+// were serialized using the CloudEvents schema, in any of the structured, batched,
+// or binary content modes.
+func IsCloudEventsRequest(r *http.Request) bool {
+	switch mediaType(r) {
+	case CloudEventsContentType, CloudEventsBatchContentType:
+		return true
+	}
+	return r.Header.Get(cloudEventsHeaderPrefix+"Specversion") != ""
+}
+
+// ReadCloudEvents reads all of the CloudEvents carried by a request. Structured
+// (`application/cloudevents+json`), batched (`application/cloudevents-batch+json`),
+// and binary (`ce-*` headers) content modes are all understood. The same limits on the size
+// of the request and of each event are enforced as by `EventDecoder`.
+func ReadCloudEvents(r *http.Request) ([]CloudEvent, error) {
+	switch mediaType(r) {
+	case CloudEventsContentType:
+		var raw json.RawMessage
+		if err := json.NewDecoder(newPayloadReader(r.Body, MaxPayloadSize)).Decode(&raw); err != nil {
+			return nil, err
+		}
+		if err := checkEventSize(0, raw); err != nil {
+			return nil, err
+		}
+
+		var event CloudEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return nil, err
+		}
+		return []CloudEvent{event}, nil
+	case CloudEventsBatchContentType:
+		dec := NewEventDecoder(r.Body)
+
+		var events []CloudEvent
+		for {
+			var event CloudEvent
+			if err := dec.decode(&event); err == io.EOF {
+				return events, nil
+			} else if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+	}
+
+	if r.Header.Get(cloudEventsHeaderPrefix+"Specversion") == "" {
+		return nil, fmt.Errorf("request with content type %q does not contain CloudEvents", r.Header.Get("Content-Type"))
+	}
+
+	event, err := readBinaryCloudEvent(r)
+	if err != nil {
+		return nil, err
+	}
+	return []CloudEvent{event}, nil
+}
+
+func readBinaryCloudEvent(r *http.Request) (event CloudEvent, err error) {
+	attribute := func(name string) string {
+		return r.Header.Get(cloudEventsHeaderPrefix + name)
+	}
+
+	event = CloudEvent{
+		ID:              attribute("Id"),
+		Source:          attribute("Source"),
+		SpecVersion:     attribute("Specversion"),
+		Type:            attribute("Type"),
+		DataContentType: r.Header.Get("Content-Type"),
+		DataSchema:      attribute("Dataschema"),
+		DataVersion:     attribute("Dataversion"),
+		Subject:         attribute("Subject"),
+		Time:            attribute("Time"),
+	}
+
+	body, err := ioutil.ReadAll(newPayloadReader(r.Body, MaxPayloadSize))
+	if err != nil || len(body) == 0 {
+		return
+	}
+
+	if err = checkEventSize(0, body); err != nil {
+		return
+	}
+
+	if isJSONMediaType(mediaType(r)) {
+		event.Data = body
+	} else {
+		event.Data, err = json.Marshal(body)
+	}
+	return
+}
+
+// readEvents reads every event in a request into memory, regardless of which of the
+// supported schemas was used to serialize them. Events which are larger than `MaxEventSize`
+// are returned along with the others, and the error describing each is recorded by its
+// index in the batch.
+func readEvents(req *http.Request) ([]Event, map[int]error, error) {
+	switch {
+	case mediaType(req) == CloudEventsBatchContentType:
+		dec := NewEventDecoder(req.Body)
+		return readEventBatch(func(e *Event) error {
+			var ce CloudEvent
+			err := dec.decode(&ce)
+			if err == nil && ce.SpecVersion != CloudEventsSpecVersion {
+				return fmt.Errorf("unsupported CloudEvents specversion %q", ce.SpecVersion)
+			}
+			*e = ce.Event()
+			return err
+		})
+	case IsCloudEventsRequest(req):
+		cloudEvents, err := ReadCloudEvents(req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		events := make([]Event, 0, len(cloudEvents))
+		for _, ce := range cloudEvents {
+			if ce.SpecVersion != CloudEventsSpecVersion {
+				return nil, nil, fmt.Errorf("unsupported CloudEvents specversion %q", ce.SpecVersion)
+			}
+			events = append(events, ce.Event())
+		}
+		return events, nil, nil
+	default:
+		return readEventBatch(NewEventDecoder(req.Body).Decode)
+	}
+}
+
+func mediaType(r *http.Request) string {
+	parsed, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed)
+}
+
+func isJSONMediaType(t string) bool {
+	return t == "application/json" || t == "text/json" || strings.HasSuffix(t, "+json")
+}
@@ -0,0 +1,249 @@
+package eventgrid_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+const sampleCloudEvent = `{
+	"specversion": "1.0",
+	"type": "Microsoft.Storage.BlobCreated",
+	"source": "/subscriptions/{subscription-id}/resourceGroups/Storage/providers/Microsoft.Storage/storageAccounts/xstoretestaccount",
+	"id": "9aeb0fdf-c01e-0131-0922-9eb54906e209",
+	"time": "2019-11-18T15:13:39.4589254Z",
+	"subject": "/blobServices/default/containers/testcontainer/blobs/testfile.txt",
+	"dataschema": "#",
+	"data": {
+		"api": "PutBlockList",
+		"contentType": "text/plain",
+		"contentLength": 524288,
+		"blobType": "BlockBlob"
+	}
+}`
+
+func ExampleReadCloudEvents() {
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(sampleCloudEvent))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/cloudevents+json; charset=utf-8")
+
+	events, err := eventgrid.ReadCloudEvents(req)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, e := range events {
+		fmt.Println(e.Type, e.ID)
+	}
+
+	// Output: Microsoft.Storage.BlobCreated 9aeb0fdf-c01e-0131-0922-9eb54906e209
+}
+
+func TestReadCloudEvents_Batch(t *testing.T) {
+	body := fmt.Sprintf("[%s, %s]", sampleCloudEvent, strings.Replace(sampleCloudEvent, "9aeb0fdf", "8aeb0fdf", 1))
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", eventgrid.CloudEventsBatchContentType)
+
+	if !eventgrid.IsCloudEventsRequest(req) {
+		t.Error("batched request was not recognized as carrying CloudEvents")
+	}
+
+	events, err := eventgrid.ReadCloudEvents(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(events), 2; got != want {
+		t.Fatalf("got: %d events want: %d", got, want)
+	}
+
+	if got, want := events[1].ID, "8aeb0fdf-c01e-0131-0922-9eb54906e209"; got != want {
+		t.Errorf("got: %q want: %q", got, want)
+	}
+}
+
+func TestReadCloudEvents_Binary(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/json", `{"name":"buffalo"}`, "buffalo"},
+		{"text/plain", "buffalo", "buffalo"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", tc.contentType)
+			req.Header.Add("ce-specversion", "1.0")
+			req.Header.Add("ce-type", "Contoso.Items.ItemReceived")
+			req.Header.Add("ce-source", "/contoso/items")
+			req.Header.Add("ce-id", "1234")
+			req.Header.Add("ce-subject", "items/1234")
+
+			if !eventgrid.IsCloudEventsRequest(req) {
+				t.Error("binary request was not recognized as carrying CloudEvents")
+			}
+
+			events, err := eventgrid.ReadCloudEvents(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := len(events), 1; got != want {
+				t.Fatalf("got: %d events want: %d", got, want)
+			}
+
+			e := events[0].Event()
+			if got, want := e.EventType, "Contoso.Items.ItemReceived"; got != want {
+				t.Errorf("got: %q want: %q", got, want)
+			}
+			if got, want := e.Subject, "items/1234"; got != want {
+				t.Errorf("got: %q want: %q", got, want)
+			}
+
+			var got string
+			if strings.HasSuffix(tc.contentType, "json") {
+				var payload struct {
+					Name string `json:"name"`
+				}
+				err = e.UnmarshalData(&payload)
+				got = payload.Name
+			} else {
+				var payload []byte
+				err = e.UnmarshalData(&payload)
+				got = string(payload)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_CloudEvents(t *testing.T) {
+	var lock sync.Mutex
+	var seen []string
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).Bind("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event) error {
+		lock.Lock()
+		defer lock.Unlock()
+		seen = append(seen, e.ID)
+		return nil
+	})
+
+	body := fmt.Sprintf("[%s, %s]", sampleCloudEvent, strings.Replace(sampleCloudEvent, "9aeb0fdf", "8aeb0fdf", 1))
+	req, err := http.NewRequest(http.MethodPost, "localhost", bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", eventgrid.CloudEventsBatchContentType)
+
+	ctx := NewMockContext(req)
+	if err = subject.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ctx.Status(), http.StatusOK; got != want {
+		t.Errorf("got: %d want: %d", got, want)
+	}
+
+	sort.Strings(seen)
+	if got, want := strings.Join(seen, ","), "8aeb0fdf-c01e-0131-0922-9eb54906e209,9aeb0fdf-c01e-0131-0922-9eb54906e209"; got != want {
+		t.Errorf("got: %q want: %q", got, want)
+	}
+}
+
+func TestCloudEvent_Event_DataVersion(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ce          eventgrid.CloudEvent
+		dataVersion string
+		dataSchema  string
+	}{
+		{"neither", eventgrid.CloudEvent{}, "", ""},
+		{"schema only", eventgrid.CloudEvent{DataSchema: "https://contoso.com/schemas/order/2.0"}, "", "https://contoso.com/schemas/order/2.0"},
+		{"extension", eventgrid.CloudEvent{DataSchema: "#", DataVersion: "2.0"}, "2.0", "#"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.ce.Event()
+			if got.DataVersion != tc.dataVersion {
+				t.Errorf("got data version: %q want: %q", got.DataVersion, tc.dataVersion)
+			}
+			if got.DataSchema != tc.dataSchema {
+				t.Errorf("got data schema: %q want: %q", got.DataSchema, tc.dataSchema)
+			}
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_CloudEventDataVersion(t *testing.T) {
+	var got string
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Contoso.Order.Created@^2", func(c buffalo.Context, e eventgrid.Event) error {
+			got = "v2"
+			return nil
+		}).
+		Bind("Contoso.Order.Created", func(c buffalo.Context, e eventgrid.Event) error {
+			got = "unversioned"
+			return nil
+		})
+
+	testCases := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"schema URI", map[string]string{"Ce-Dataschema": "https://contoso.com/schemas/order/2.0"}, "unversioned"},
+		{"extension", map[string]string{"Ce-Dataschema": "https://contoso.com/schemas/order/2.0", "Ce-Dataversion": "2.1"}, "v2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got = ""
+
+			req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`{"id": "A-1"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Ce-Specversion", eventgrid.CloudEventsSpecVersion)
+			req.Header.Set("Ce-Id", "1")
+			req.Header.Set("Ce-Source", "/contoso/orders")
+			req.Header.Set("Ce-Type", "Contoso.Order.Created")
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			if status := receiveStatus(subject, NewMockContext(req)); status != http.StatusOK {
+				t.Errorf("got status: %d want: %d", status, http.StatusOK)
+			}
+			if got != tc.want {
+				t.Errorf("got handler: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package eventgrid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// eventConsole is implemented by Subscribers which are able to show a development console,
+// sending the Events created with it to a particular Receive action.
+type eventConsole interface {
+	console(c buffalo.Context, receive buffalo.Handler) error
+}
+
+// consoleHandler creates the action which shows the development console of a Subscriber,
+// sending the Events created with it to receive: the handler, wrapped in the same middleware,
+// that the Subscriber's routes use for deliveries from an Event Grid Topic. Subscribers which
+// do not have a console are left to answer using their New action.
+func consoleHandler(s Subscriber, receive buffalo.Handler) buffalo.Handler {
+	if console, ok := s.(eventConsole); ok {
+		return func(c buffalo.Context) error {
+			return console.console(c, receive)
+		}
+	}
+	return s.New
+}
+
+// consoleEvent is a sample of an Event that may be sent using the development console.
+type consoleEvent struct {
+	EventType   string
+	Subject     string
+	DataVersion string
+	Data        string
+}
+
+// consoleForm holds what is needed to show a form for sending a sample Event, including the
+// token that guards against cross-site request forgery, if there is one.
+type consoleForm struct {
+	Token  interface{}
+	Sample consoleEvent
+}
+
+// New shows a development console, which `RegisterSubscriber` and `App.Subscriber` route to
+// in the "development" environment. It lists each Event Type bound to this subscriber, along
+// with a sample Event which may be edited and then sent to the Receive action, without
+// involving an Event Grid Topic. The status the Event was answered with, and the outcome of
+// each Event, are shown once it has been processed.
+//
+// When routed to by `RegisterSubscriber` or `App.Subscriber`, Events are sent through the
+// same middleware as deliveries to the Receive route, including authentication, so they are
+// answered as a delivery from an Event Grid Topic would be. The query string of the console's
+// own URL is sent along, so that it may carry a shared secret. Called directly, New hands
+// Events straight to Receive.
+//
+// The data of each sample is a value of the type read by the handler bound using `BindTyped`,
+// or else of the type registered for the Event Type in the `DefaultTypeRegistry`. Pointers are
+// populated, so that every property of the data is shown.
+func (s TypeDispatchSubscriber) New(c buffalo.Context) error {
+	return s.console(c, s.Receive)
+}
+
+func (s TypeDispatchSubscriber) console(c buffalo.Context, receive buffalo.Handler) error {
+	token := c.Value("authenticity_token")
+
+	samples := s.consoleEvents()
+	forms := make([]consoleForm, 0, len(samples))
+	for _, sample := range samples {
+		forms = append(forms, consoleForm{Token: token, Sample: sample})
+	}
+
+	data := map[string]interface{}{
+		"forms":  forms,
+		"custom": consoleForm{Token: token, Sample: consoleEvent{Data: "{}"}},
+	}
+
+	if c.Request().Method == http.MethodPost {
+		sent, err := readConsoleEvent(c.Request())
+		if err != nil {
+			data["problem"] = err.Error()
+		} else {
+			status, results := sendConsoleEvent(c, receive, sent)
+			data["sent"], data["status"], data["results"] = sent, status, results
+		}
+
+		data["custom"] = consoleForm{Token: token, Sample: consoleEvent{
+			EventType:   c.Request().PostForm.Get("event_type"),
+			Subject:     c.Request().PostForm.Get("subject"),
+			DataVersion: c.Request().PostForm.Get("data_version"),
+			Data:        c.Request().PostForm.Get("data"),
+		}}
+	}
+
+	c.Response().Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	if err := consoleTemplate.Execute(c.Response(), data); err != nil {
+		return c.Error(http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+// consoleEvents creates a sample Event for each binding of an Event Type that is not a pattern.
+func (s TypeDispatchSubscriber) consoleEvents() []consoleEvent {
+	var samples []consoleEvent
+	for eventType, bindings := range s.bindings {
+		if isEventTypePattern(eventType) || eventType == s.NormalizeEventType(EventTypeWildcard) {
+			continue
+		}
+
+		for _, b := range bindings {
+			sample := consoleEvent{
+				EventType: b.eventType,
+				Subject:   "/sample",
+				Data:      "{}",
+			}
+			if b.subject != nil {
+				sample.Subject = b.subject.raw
+			}
+			if b.version != nil && isVersionLabel(b.version.raw) {
+				sample.DataVersion = b.version.raw
+			}
+
+			dataType := b.dataType
+			if dataType == nil {
+				dataType, _ = DefaultTypeRegistry.Lookup(b.eventType, sample.DataVersion)
+			}
+			if dataType != nil {
+				if marshaled, err := json.MarshalIndent(sampleValue(dataType, 0).Interface(), "", "  "); err == nil {
+					sample.Data = string(marshaled)
+				}
+			}
+
+			samples = append(samples, sample)
+		}
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].EventType < samples[j].EventType
+	})
+	return samples
+}
+
+// sampleDepth is how deeply nested a value created by sampleValue may be, so that types which
+// refer to themselves do not cause it to recurse forever.
+const sampleDepth = 6
+
+// sampleValue creates a zero value of a type, except that pointers are populated, as are the
+// exported fields of structs. Slices and maps are empty rather than nil.
+func sampleValue(t reflect.Type, depth int) reflect.Value {
+	if depth > sampleDepth {
+		return reflect.Zero(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		created := reflect.New(t.Elem())
+		created.Elem().Set(sampleValue(t.Elem(), depth+1))
+		return created
+	case reflect.Struct:
+		created := reflect.New(t).Elem()
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.PkgPath == "" {
+				created.Field(i).Set(sampleValue(field.Type, depth+1))
+			}
+		}
+		return created
+	case reflect.Slice:
+		return reflect.MakeSlice(t, 0, 0)
+	case reflect.Map:
+		return reflect.MakeMap(t)
+	default:
+		return reflect.Zero(t)
+	}
+}
+
+// readConsoleEvent reads an Event that was submitted using the development console.
+func readConsoleEvent(r *http.Request) (Event, error) {
+	if err := r.ParseForm(); err != nil {
+		return Event{}, err
+	}
+
+	sent := Event{
+		ID:              "console-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		EventType:       r.PostForm.Get("event_type"),
+		Subject:         r.PostForm.Get("subject"),
+		DataVersion:     r.PostForm.Get("data_version"),
+		MetadataVersion: "1",
+		EventTime:       time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if sent.EventType == "" {
+		return Event{}, fmt.Errorf("an event type is required")
+	}
+
+	data := bytes.TrimSpace([]byte(r.PostForm.Get("data")))
+	if len(data) == 0 {
+		data = []byte("null")
+	}
+	if !json.Valid(data) {
+		return Event{}, fmt.Errorf("data of event %q is not valid JSON", sent.EventType)
+	}
+	sent.Data = data
+
+	return sent, nil
+}
+
+// sendConsoleEvent hands an Event to a Receive action, as though it had been delivered by an
+// Event Grid Topic to the route beneath which the console is found, and reports the status
+// of the response along with the outcome of the Event.
+func sendConsoleEvent(c buffalo.Context, receive buffalo.Handler, sent Event) (int, BatchResult) {
+	body, err := json.Marshal([]Event{sent})
+	if err != nil {
+		return http.StatusInternalServerError, BatchResult{{ID: sent.ID, EventType: sent.EventType, Status: http.StatusInternalServerError, Err: err}}
+	}
+
+	target := *c.Request().URL
+	target.Path = strings.TrimSuffix(strings.TrimSuffix(target.Path, "/"), "new")
+
+	req, err := http.NewRequest(http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, BatchResult{{ID: sent.ID, EventType: sent.EventType, Status: http.StatusInternalServerError, Err: err}}
+	}
+	req = req.WithContext(c.Request().Context())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := &consoleResponse{header: make(http.Header)}
+	sendCtx := consoleContext{Context: c, request: req, response: resp}
+
+	started := time.Now()
+	err = receive(sendCtx)
+	elapsed := time.Since(started)
+
+	// An error is answered with its own status, as buffalo would. Otherwise, the status that
+	// was written to the response is the one the Event was answered with.
+	status := errorStatus(err)
+	if err == nil && resp.status != 0 {
+		status = resp.status
+	}
+
+	results, ok := BatchResultFromContext(sendCtx)
+	if !ok {
+		results = BatchResult{requestResult(0, sent, err, elapsed)}
+		results[0].Status = status
+	}
+	return status, results
+}
+
+// consoleContext replaces the request and response of a `buffalo.Context`, so that an Event
+// sent using the development console may be processed while answering the request for it.
+type consoleContext struct {
+	buffalo.Context
+	request  *http.Request
+	response http.ResponseWriter
+}
+
+func (c consoleContext) Request() *http.Request {
+	return c.request
+}
+
+func (c consoleContext) Response() http.ResponseWriter {
+	return c.response
+}
+
+// consoleResponse records the status written in response to an Event sent using the
+// development console, and throws away the body.
+type consoleResponse struct {
+	header http.Header
+	status int
+}
+
+func (w *consoleResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *consoleResponse) Write(x []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(x), nil
+}
+
+func (w *consoleResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+var consoleTemplate = template.Must(template.New("console").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Event Grid Console</title>
+</head>
+<body>
+	<h1>Event Grid Console</h1>
+	<p>Events sent from this page are handed to this subscriber as though they were delivered by an Event Grid Topic, without involving one.</p>
+	{{- if .problem}}
+	<p><strong>Unable to send event:</strong> {{.problem}}</p>
+	{{- end}}
+	{{- if .results}}
+	<h2>Sent {{.sent.EventType}} ({{.sent.ID}}), answered with HTTP {{.status}}</h2>
+	<table>
+		<thead>
+			<tr><th>ID</th><th>Event Type</th><th>Status</th><th>Duration</th><th>Error</th></tr>
+		</thead>
+		<tbody>
+		{{- range .results}}
+			<tr><td>{{.ID}}</td><td>{{.EventType}}</td><td>{{.Status}}</td><td>{{.Duration}}</td><td>{{if .Err}}{{.Err}}{{end}}</td></tr>
+		{{- end}}
+		</tbody>
+	</table>
+	{{- end}}
+	{{- range .forms}}
+	<h2>{{.Sample.EventType}}</h2>
+	{{- template "form" .}}
+	{{- else}}
+	<p>No event types are bound to this subscriber.</p>
+	{{- end}}
+	<h2>Custom Event</h2>
+	{{- template "form" .custom}}
+</body>
+</html>
+{{define "form"}}
+	<form method="post">
+		{{- if .Token}}
+		<input type="hidden" name="authenticity_token" value="{{.Token}}">
+		{{- end}}
+		<input name="event_type" placeholder="Event Type" value="{{.Sample.EventType}}">
+		<input name="subject" placeholder="Subject" value="{{.Sample.Subject}}">
+		<input name="data_version" placeholder="Data Version" value="{{.Sample.DataVersion}}">
+		<textarea name="data" rows="10" cols="80">{{.Sample.Data}}</textarea>
+		<button type="submit">Send</button>
+	</form>
+{{- end}}
+`))
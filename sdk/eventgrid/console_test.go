@@ -0,0 +1,193 @@
+package eventgrid_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+type consoleOrder struct {
+	Number   string            `json:"number"`
+	Customer *consoleCustomer  `json:"customer"`
+	Lines    []string          `json:"lines"`
+	Tags     map[string]string `json:"tags"`
+}
+
+type consoleCustomer struct {
+	Name string `json:"name"`
+}
+
+func newConsoleSubscriber() *eventgrid.TypeDispatchSubscriber {
+	return eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		BindTyped("Contoso.Order.Created", func(c buffalo.Context, e eventgrid.Event, order *consoleOrder) error {
+			if order.Number == "" {
+				return c.Error(http.StatusBadRequest, errors.New("order number is required"))
+			}
+			return nil
+		}).
+		Bind("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		}).
+		Bind("Contoso.Invoice.*", func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		}).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		})
+}
+
+// console hands a request to the New action of a Subscriber, and reads the page it responds with.
+func console(t *testing.T, s eventgrid.Subscriber, form url.Values) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/events/new", nil)
+	if form != nil {
+		req = httptest.NewRequest(http.MethodPost, "/events/new", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	ctx := NewMockContext(req)
+	if err := s.New(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := ctx.Status(); got != http.StatusOK {
+		t.Errorf("got status: %d want: %d", got, http.StatusOK)
+	}
+
+	body, err := ioutil.ReadAll(ctx.Body())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestTypeDispatchSubscriber_New(t *testing.T) {
+	page := console(t, newConsoleSubscriber(), nil)
+
+	for _, want := range []string{
+		`value="Contoso.Order.Created"`,
+		`&#34;customer&#34;: {`,
+		`&#34;name&#34;: &#34;&#34;`,
+		`&#34;lines&#34;: []`,
+		`value="Microsoft.Storage.BlobCreated"`,
+		`&#34;api&#34;: &#34;&#34;`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected the console to contain %q, got:\n%s", want, page)
+		}
+	}
+
+	for _, unwanted := range []string{"Contoso.Invoice.*", `value="all"`} {
+		if strings.Contains(page, unwanted) {
+			t.Errorf("expected the console not to offer %q", unwanted)
+		}
+	}
+}
+
+func TestTypeDispatchSubscriber_New_Send(t *testing.T) {
+	subject := newConsoleSubscriber()
+
+	testCases := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"succeed", `{"number": "A-1"}`, []string{"answered with HTTP 200", "<td>Contoso.Order.Created</td><td>200</td>"}},
+		{"reject", `{}`, []string{"answered with HTTP 500", "<td>400</td>", "order number is required"}},
+		{"invalid", `{`, []string{"Unable to send event", "is not valid JSON"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page := console(t, subject, url.Values{
+				"event_type": {"Contoso.Order.Created"},
+				"subject":    {"/orders/1"},
+				"data":       {tc.data},
+			})
+
+			for _, want := range tc.want {
+				if !strings.Contains(page, want) {
+					t.Errorf("expected the console to contain %q, got:\n%s", want, page)
+				}
+			}
+		})
+	}
+}
+
+func TestCachingSubscriber_New(t *testing.T) {
+	subject := eventgrid.NewCachingSubscriber(newConsoleSubscriber(), nil)
+
+	console(t, subject, url.Values{
+		"event_type": {"Contoso.Order.Created"},
+		"data":       {`{"number": "A-1"}`},
+	})
+
+	received, _ := subject.Store.List(context.Background())
+	if len(received) != 1 || received[0].EventType != "Contoso.Order.Created" {
+		t.Errorf("got: %+v want the event sent using the console to be recorded", received)
+	}
+}
+
+// acceptingSubscriber answers every delivery with an HTTP 202, without returning an error.
+type acceptingSubscriber struct {
+	*eventgrid.TypeDispatchSubscriber
+}
+
+func (acceptingSubscriber) Receive(c buffalo.Context) error {
+	c.Response().WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+func TestRegisterSubscriber_Console(t *testing.T) {
+	send := func(app *buffalo.App, target string) string {
+		form := url.Values{
+			"event_type": {"Contoso.Order.Created"},
+			"data":       {`{"number": "A-1"}`},
+		}
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, req)
+		if resp.Code != http.StatusOK {
+			t.Errorf("%s: got status: %d want: %d", target, resp.Code, http.StatusOK)
+		}
+		return resp.Body.String()
+	}
+
+	authenticated := buffalo.New(buffalo.Options{Env: "development"})
+	eventgrid.RegisterSubscriber(authenticated, "/events", newConsoleSubscriber(), eventgrid.WithAuthentication(eventgrid.SharedSecretAuthenticator{
+		Secret:         "buffalo",
+		QueryParameter: "code",
+	}))
+
+	accepting := buffalo.New(buffalo.Options{Env: "development"})
+	eventgrid.RegisterSubscriber(accepting, "/events", acceptingSubscriber{newConsoleSubscriber()})
+
+	testCases := []struct {
+		name   string
+		app    *buffalo.App
+		target string
+		want   string
+	}{
+		{"unauthenticated", authenticated, "/events/new", "answered with HTTP 401"},
+		{"authenticated", authenticated, "/events/new?code=buffalo", "answered with HTTP 200"},
+		{"written status", accepting, "/events/new", "answered with HTTP 202"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if page := send(tc.app, tc.target); !strings.Contains(page, tc.want) {
+				t.Errorf("expected the console to contain %q, got:\n%s", tc.want, page)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package eventgrid
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/buffalo/render"
@@ -21,22 +25,41 @@ var successStatusCodes = map[int]struct{}{
 	http.StatusCreated: struct{}{},
 }
 
+// DefaultBatchTimeout is the amount of time a batch of Events is given to be processed,
+// when no other value has been specified. It is kept below the 30 seconds an Event Grid
+// Topic waits for a response, so that the batch is answered before the Topic gives up on it.
+const DefaultBatchTimeout = 25 * time.Second
+
 // Context extends `buffalo.Context` to ease communication between a Request Handler
 // and an Event Grid Topic.
+//
+// When created by a `TypeDispatchSubscriber`, each Event in a batch is given its own
+// child Context. Values stored using `Set` and `Flash` in one child are not visible to
+// the others, and the parent aggregates the outcome of each child.
 type Context struct {
 	buffalo.Context
-	resp  *ResponseWriter
-	data  map[string]interface{}
-	flash buffalo.Flash
+	ctx      context.Context
+	resp     *ResponseWriter
+	data     map[string]interface{}
+	dataLock *sync.RWMutex
+	flash    buffalo.Flash
+	event    *Event
+	index    int
+	params   map[string]string
+	started  time.Time
 }
 
 // NewContext initializes a new `eventgrid.Context`.
 func NewContext(parent buffalo.Context) (created *Context) {
 	created = &Context{
-		Context: parent,
-		resp:    NewResponseWriter(),
-		data:    make(map[string]interface{}, len(parent.Data())),
+		Context:  parent,
+		ctx:      parent,
+		resp:     NewResponseWriter(),
+		data:     make(map[string]interface{}, len(parent.Data())),
+		dataLock: &sync.RWMutex{},
+		index:    -1,
 	}
+	created.flash.Clear()
 
 	for k, v := range parent.Data() {
 		created.data[k] = v
@@ -45,9 +68,147 @@ func NewContext(parent buffalo.Context) (created *Context) {
 	return
 }
 
+// newEventContext creates a child Context dedicated to processing a single Event. The
+// child starts with a copy of its parent's data, and a `context.Context` which is cancelled
+// along with its parent's. When timeout is greater than zero, it is also cancelled once that
+// much time has elapsed.
+func (c *Context) newEventContext(e Event, index int, timeout time.Duration) (*Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(c.ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(c.ctx)
+	}
+
+	created := &Context{
+		Context:  c.Context,
+		ctx:      ctx,
+		resp:     c.resp,
+		data:     c.Data(),
+		dataLock: &sync.RWMutex{},
+		event:    &e,
+		index:    index,
+		started:  time.Now(),
+	}
+	created.flash.Clear()
+
+	return created, cancel
+}
+
+// Event fetches the Event being processed using this Context, if there is one.
+func (c *Context) Event() (Event, bool) {
+	if c.event == nil {
+		return Event{}, false
+	}
+	return *c.event, true
+}
+
+// Index fetches the position in its batch of the Event being processed using this
+// Context. When no Event is associated with this Context, -1 is returned.
+func (c *Context) Index() int {
+	return c.index
+}
+
+// Param fetches a parameter captured from the Subject of the Event being processed, falling
+// back to the parameters of the request which delivered it.
+func (c *Context) Param(key string) string {
+	return c.Params().Get(key)
+}
+
+// Params fetches the parameters captured from the Subject of the Event being processed,
+// along with the parameters of the request which delivered it.
+func (c *Context) Params() buffalo.ParamValues {
+	return subjectParamValues{captured: c.params, parent: c.Context.Params()}
+}
+
+type contextKey string
+
+const (
+	eventContextKey contextKey = "event"
+	indexContextKey contextKey = "index"
+)
+
+// EventFromContext fetches the Event being processed using a particular `buffalo.Context`.
+// This allows middleware to inspect the Event that is about to be, or was just, handled.
+func EventFromContext(c context.Context) (Event, bool) {
+	e, ok := c.Value(eventContextKey).(Event)
+	return e, ok
+}
+
+// IndexFromContext fetches the position in its batch of the Event being processed using
+// a particular `buffalo.Context`.
+func IndexFromContext(c context.Context) (int, bool) {
+	i, ok := c.Value(indexContextKey).(int)
+	return i, ok
+}
+
+// Deadline reports when work done on behalf of this Context should be abandoned.
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.ctx.Deadline()
+}
+
+// Done is closed when work done on behalf of this Context should be abandoned.
+func (c *Context) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Err explains why Done was closed.
+func (c *Context) Err() error {
+	return c.ctx.Err()
+}
+
+// Value fetches a value that was stored using `Set`, falling back to values held by
+// the parent Context.
+func (c *Context) Value(key interface{}) interface{} {
+	switch key {
+	case eventContextKey:
+		if c.event != nil {
+			return *c.event
+		}
+	case indexContextKey:
+		if c.event != nil {
+			return c.index
+		}
+	}
+
+	if k, ok := key.(string); ok {
+		c.dataLock.RLock()
+		v, ok := c.data[k]
+		c.dataLock.RUnlock()
+		if ok {
+			return v
+		}
+	}
+	return c.ctx.Value(key)
+}
+
+// Set stores a value which is only visible to this Context.
+func (c *Context) Set(key string, value interface{}) {
+	c.dataLock.Lock()
+	defer c.dataLock.Unlock()
+
+	c.data[key] = value
+}
+
+// Data fetches a copy of all values stored in this Context.
+func (c *Context) Data() map[string]interface{} {
+	c.dataLock.RLock()
+	defer c.dataLock.RUnlock()
+
+	copied := make(map[string]interface{}, len(c.data))
+	for k, v := range c.data {
+		copied[k] = v
+	}
+	return copied
+}
+
 // Response fulfills Buffalo's requirement to allow folks to write a response,
 // but it actually just throws away anything you write to it.
 func (c *Context) Response() http.ResponseWriter {
+	if c.event != nil {
+		return eventResponseWriter{ResponseWriter: c.resp, index: c.index, event: *c.event}
+	}
 	return c.resp
 }
 
@@ -57,8 +218,13 @@ func (c *Context) ResponseHasFailure() bool {
 	return c.resp.HasFailure()
 }
 
+// Results reads the outcome of each Event processed using this Context so far.
+func (c *Context) Results() BatchResult {
+	return c.resp.Results()
+}
+
 func (c *Context) Error(status int, err error) error {
-	c.resp.WriteHeader(status)
+	c.writeStatus(status, err)
 	if logger := c.Logger(); logger != nil {
 		logger.Error(err)
 	}
@@ -71,7 +237,7 @@ func (c *Context) Render(status int, r render.Renderer) error {
 	if logger := c.Logger(); logger != nil {
 		c.Logger().Info("Event processed with Status Code: %d ", status)
 	}
-	c.resp.WriteHeader(status)
+	c.writeStatus(status, nil)
 	return r.Render(c.Response(), c.Data())
 }
 
@@ -82,15 +248,62 @@ func (c *Context) Flash() *buffalo.Flash {
 
 // Redirect informs the Event Grid Topic that an Event was unable to be handled.
 func (c *Context) Redirect(status int, url string, args ...interface{}) error {
-	c.resp.WriteHeader(status)
+	c.writeStatus(status, nil)
 	return nil
 }
 
+// finish records the outcome of an Event once its handler has returned. A handler that
+// returned an error without reporting a failing status is recorded as an HTTP 500, and
+// one that reported nothing at all is recorded as an HTTP 200. How long the handler ran
+// for is recorded alongside.
+func (c *Context) finish(err error) {
+	if c.event == nil {
+		return
+	}
+
+	result, ok := c.resp.Result(c.index)
+	switch {
+	case err != nil && (!ok || result.Succeeded()):
+		c.resp.Record(c.index, *c.event, http.StatusInternalServerError, err)
+	case err != nil && result.Err == nil:
+		c.resp.Record(c.index, *c.event, result.Status, err)
+	case !ok:
+		c.resp.Record(c.index, *c.event, http.StatusOK, nil)
+	}
+	c.resp.seal(c.index, time.Since(c.started))
+}
+
+// abandon records that an Event was not processed before its Context's deadline. Any
+// outcome later reported by its Handler is ignored. An Event whose outcome is already final
+// is left alone.
+func (c *Context) abandon() {
+	if c.event == nil || c.resp.isSealed(c.index) {
+		return
+	}
+
+	err := fmt.Errorf("event was not processed in time: %v", c.Err())
+	if logger := c.Logger(); logger != nil {
+		logger.Error(err)
+	}
+	c.resp.Record(c.index, *c.event, http.StatusGatewayTimeout, err)
+	c.resp.seal(c.index, time.Since(c.started))
+}
+
+func (c *Context) writeStatus(status int, err error) {
+	if c.event != nil {
+		c.resp.Record(c.index, *c.event, status, err)
+		return
+	}
+	c.resp.WriteHeader(status)
+}
+
 // ResponseWriter looks like an `http.ResponseWriter`, but
 type ResponseWriter struct {
 	sync.RWMutex
 	failureSeen bool
 	header      http.Header
+	results     map[int]*EventResult
+	sealed      map[int]struct{}
 }
 
 // NewResponseWriter initializes a ResponseWriter which will merge the responses of
@@ -99,9 +312,120 @@ func NewResponseWriter() *ResponseWriter {
 	return &ResponseWriter{
 		failureSeen: false,
 		header:      make(http.Header),
+		results:     make(map[int]*EventResult),
+		sealed:      make(map[int]struct{}),
 	}
 }
 
+// Record associates a Status Code and error with the Event at a particular position in its
+// batch. Outcomes are kept by position rather than by ID, because IDs are not guaranteed to
+// be unique within a batch. Once a failing Status Code has been recorded for an Event, later
+// successful ones are ignored. Once the outcome of an Event is final, anything further
+// recorded for it is ignored.
+func (w *ResponseWriter) Record(index int, e Event, status int, err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.sealed[index]; ok {
+		return
+	}
+
+	_, succeeded := SuccessStatusCodes()[status]
+	if !succeeded {
+		w.failureSeen = true
+	}
+
+	existing, ok := w.results[index]
+	if !ok {
+		w.results[index] = &EventResult{
+			Index:     index,
+			ID:        e.ID,
+			EventType: e.EventType,
+			Status:    status,
+			Err:       err,
+		}
+		return
+	}
+
+	if existing.Succeeded() || !succeeded {
+		existing.Status = status
+	}
+	if existing.Err == nil {
+		existing.Err = err
+	}
+}
+
+// replace discards any outcome recorded so far for an Event, in favor of the one provided.
+func (w *ResponseWriter) replace(index int, e Event, status int, err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.sealed[index]; ok {
+		return
+	}
+
+	w.results[index] = &EventResult{
+		Index:     index,
+		ID:        e.ID,
+		EventType: e.EventType,
+		Status:    status,
+		Err:       err,
+	}
+}
+
+// seal marks the outcome of an Event as final, and records how long it took to reach.
+func (w *ResponseWriter) seal(index int, duration time.Duration) {
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.sealed[index]; ok {
+		return
+	}
+	if result, ok := w.results[index]; ok {
+		result.Duration = duration
+	}
+	w.sealed[index] = struct{}{}
+}
+
+// isSealed reports whether the outcome of an Event is final.
+func (w *ResponseWriter) isSealed(index int) bool {
+	w.RLock()
+	defer w.RUnlock()
+
+	_, ok := w.sealed[index]
+	return ok
+}
+
+// Result fetches the outcome recorded for the Event at a particular position in its batch.
+func (w *ResponseWriter) Result(index int) (EventResult, bool) {
+	w.RLock()
+	defer w.RUnlock()
+
+	if result, ok := w.results[index]; ok {
+		return *result, true
+	}
+	return EventResult{}, false
+}
+
+// Results reads the outcome of every Event recorded by this ResponseWriter, in the
+// order the Events appear in their batch.
+func (w *ResponseWriter) Results() BatchResult {
+	w.RLock()
+	defer w.RUnlock()
+
+	indexes := make([]int, 0, len(w.results))
+	for index := range w.results {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	results := make(BatchResult, 0, len(indexes))
+	for _, index := range indexes {
+		results = append(results, *w.results[index])
+	}
+	return results
+}
+
 // Header gets the Headers associated with this Response writer.
 func (w *ResponseWriter) Header() http.Header {
 	w.RLock()
@@ -148,3 +472,15 @@ func (w *ResponseWriter) WriteHeader(s int) {
 		w.SetFailure()
 	}
 }
+
+// eventResponseWriter attributes any Status Code written to it to a particular Event.
+type eventResponseWriter struct {
+	*ResponseWriter
+	index int
+	event Event
+}
+
+// WriteHeader records the Status Code as the outcome of processing this writer's Event.
+func (w eventResponseWriter) WriteHeader(s int) {
+	w.ResponseWriter.Record(w.index, w.event, s, nil)
+}
@@ -2,20 +2,23 @@ package eventgrid_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Azure/buffalo-azure/sdk/eventgrid"
 	"github.com/gobuffalo/buffalo"
 )
 
 func ExampleContext() {
-	ctx := eventgrid.NewContext(&buffalo.DefaultContext{})
+	ctx := eventgrid.NewContext(NewMockContext(nil))
 
 	var wg sync.WaitGroup
 
@@ -44,7 +47,7 @@ func ExampleContext() {
 }
 
 func TestContext_ResponseWriter_Mask(t *testing.T) {
-	var outer buffalo.Context = &buffalo.DefaultContext{}
+	var outer buffalo.Context = NewMockContext(nil)
 	inner := eventgrid.NewContext(outer)
 
 	if inner.Response() == outer.Response() {
@@ -56,20 +59,64 @@ type MockContext struct {
 	buffalo.Context
 	request *http.Request
 	*MockResponseWriter
+	data     map[string]interface{}
+	dataLock *sync.RWMutex
 }
 
 func NewMockContext(req *http.Request) *MockContext {
 	return &MockContext{
-		Context:            &buffalo.DefaultContext{},
+		Context:            &buffalo.DefaultContext{Context: context.Background()},
 		request:            req,
 		MockResponseWriter: NewMockResponseWriter(),
+		data:               make(map[string]interface{}),
+		dataLock:           &sync.RWMutex{},
 	}
 }
 
+func (c MockContext) Set(key string, value interface{}) {
+	c.dataLock.Lock()
+	defer c.dataLock.Unlock()
+	c.data[key] = value
+}
+
+func (c MockContext) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		c.dataLock.RLock()
+		defer c.dataLock.RUnlock()
+		if v, ok := c.data[k]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func (c MockContext) Data() map[string]interface{} {
+	c.dataLock.RLock()
+	defer c.dataLock.RUnlock()
+	copied := make(map[string]interface{}, len(c.data))
+	for k, v := range c.data {
+		copied[k] = v
+	}
+	return copied
+}
+
 func (c MockContext) Request() *http.Request {
 	return c.request
 }
 
+// Params reads the parameters of the request from its query string, as route parameters
+// are not captured without a router.
+func (c MockContext) Params() buffalo.ParamValues {
+	if c.request == nil {
+		return url.Values{}
+	}
+	return c.request.URL.Query()
+}
+
+func (c MockContext) Param(key string) string {
+	return c.Params().Get(key)
+}
+
 func (c MockContext) Response() http.ResponseWriter {
 	return c.MockResponseWriter
 }
@@ -110,3 +157,72 @@ func (w *MockResponseWriter) Body() io.Reader {
 func (w *MockResponseWriter) Status() int {
 	return w.status
 }
+
+func TestTypeDispatchSubscriber_Receive_ContextIsolation(t *testing.T) {
+	var lock sync.Mutex
+	seen := make(map[string]interface{})
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+		c.Set("owner", e.ID)
+		time.Sleep(10 * time.Millisecond)
+
+		got, ok := eventgrid.EventFromContext(c)
+		if !ok || got.ID != e.ID {
+			t.Errorf("EventFromContext got: %q want: %q", got.ID, e.ID)
+		}
+
+		index, ok := eventgrid.IndexFromContext(c)
+		if !ok || fmt.Sprint(index) != e.ID {
+			t.Errorf("IndexFromContext got: %d want: %s", index, e.ID)
+		}
+
+		if _, ok := c.Deadline(); !ok {
+			t.Error("event Context has no deadline")
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		seen[e.ID] = c.Value("owner")
+		return nil
+	})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.A", "Contoso.B", "Contoso.C", "Contoso.D"))
+	ctx.Set("shared", true)
+	if got, want := receiveStatus(subject, ctx), http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	for id, owner := range seen {
+		if owner != id {
+			t.Errorf("event %q saw a value set while processing event %q", id, owner)
+		}
+	}
+
+	if ctx.Value("owner") != nil {
+		t.Error("a value set while processing an event leaked into the request Context")
+	}
+}
+
+func TestContext_Value_Inherited(t *testing.T) {
+	parent := NewMockContext(nil)
+	parent.Set("greeting", "hello")
+
+	subject := eventgrid.NewContext(parent)
+	subject.Set("farewell", "goodbye")
+
+	if got, want := subject.Value("greeting"), "hello"; got != want {
+		t.Errorf("got: %v want: %v", got, want)
+	}
+
+	if got, want := subject.Value("farewell"), "goodbye"; got != want {
+		t.Errorf("got: %v want: %v", got, want)
+	}
+
+	if got := parent.Value("farewell"); got != nil {
+		t.Errorf("value leaked into parent: %v", got)
+	}
+
+	if _, ok := eventgrid.EventFromContext(subject); ok {
+		t.Error("a Context not associated with an event reported one")
+	}
+}
@@ -0,0 +1,252 @@
+package eventgrid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dataVersion is a DataVersion read as a semantic version. Missing minor and patch numbers
+// are treated as zero, so "1", "1.0", and "1.0.0" are all equivalent.
+type dataVersion struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseDataVersion reads a DataVersion like "1", "2.1", or "v1.0.0-beta". The number of
+// components which were present is also reported.
+func parseDataVersion(raw string) (v dataVersion, parts int, err error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if i := strings.IndexAny(raw, "-+"); i >= 0 {
+		if raw[i] == '-' {
+			v.prerelease = strings.SplitN(raw[i+1:], "+", 2)[0]
+		}
+		raw = raw[:i]
+	}
+
+	components := strings.Split(raw, ".")
+	if len(components) > 3 {
+		return dataVersion{}, 0, fmt.Errorf("%q has too many components to be a version", raw)
+	}
+
+	numbers := make([]int, 3)
+	for i, component := range components {
+		if numbers[i], err = strconv.Atoi(component); err != nil || numbers[i] < 0 {
+			return dataVersion{}, 0, fmt.Errorf("%q is not a valid version", raw)
+		}
+	}
+
+	v.major, v.minor, v.patch = numbers[0], numbers[1], numbers[2]
+	return v, len(components), nil
+}
+
+// compare orders two versions, returning a negative number when v precedes other, a positive
+// number when it follows other, and zero when they are equivalent.
+func (v dataVersion) compare(other dataVersion) int {
+	switch {
+	case v.major != other.major:
+		return v.major - other.major
+	case v.minor != other.minor:
+		return v.minor - other.minor
+	case v.patch != other.patch:
+		return v.patch - other.patch
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	default:
+		return strings.Compare(v.prerelease, other.prerelease)
+	}
+}
+
+// versionComparison is a single requirement of a versionConstraint, like ">=1.2".
+type versionComparison struct {
+	op      string
+	version dataVersion
+}
+
+func (vc versionComparison) allows(v dataVersion) bool {
+	result := v.compare(vc.version)
+	switch vc.op {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "!=":
+		return result != 0
+	default:
+		return result == 0
+	}
+}
+
+// versionConstraint describes the DataVersions an EventHandler or type is meant for.
+//
+// A constraint is made of comparisons like "1.0", ">=1.2", "<2", or "!=1.3", which must all be
+// satisfied, separated by commas or spaces. Alternatives are separated by "||". The shorthands
+// "1.x" (or "1.*"), "^1.2" (the same major version), and "~1.2" (the same minor version) are
+// also understood, as is "*", which allows any DataVersion at all. A DataVersion which is
+// identical to the constraint is always allowed, so a constraint like "preview", which is not
+// made of versions, allows only that exact DataVersion.
+type versionConstraint struct {
+	raw          string
+	alternatives [][]versionComparison
+}
+
+func parseVersionConstraint(raw string) (*versionConstraint, error) {
+	created := &versionConstraint{raw: strings.TrimSpace(raw)}
+	if created.raw == "" {
+		return nil, fmt.Errorf("data version constraint must not be empty")
+	}
+
+	if isVersionLabel(created.raw) {
+		if _, _, err := parseDataVersion(created.raw); err != nil {
+			return created, nil
+		}
+	}
+
+	for _, alternative := range strings.Split(created.raw, "||") {
+		terms := strings.Fields(strings.Replace(alternative, ",", " ", -1))
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("data version constraint %q has an empty alternative", raw)
+		}
+
+		var comparisons []versionComparison
+		for i := 0; i < len(terms); i++ {
+			term := terms[i]
+			if strings.Trim(term, "<>=!") == "" && i+1 < len(terms) {
+				// An operator separated from its version by a space, as in ">= 1.0".
+				i++
+				term += terms[i]
+			}
+
+			if term == "*" || term == "x" || term == "X" {
+				continue
+			}
+
+			parsed, err := parseVersionTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("data version constraint %q is invalid: %v", raw, err)
+			}
+			comparisons = append(comparisons, parsed...)
+		}
+		created.alternatives = append(created.alternatives, comparisons)
+	}
+	return created, nil
+}
+
+// isVersionLabel determines whether a constraint is a single DataVersion, without operators,
+// wildcards, or alternatives.
+func isVersionLabel(raw string) bool {
+	return !strings.ContainsAny(raw, "<>=!^~*|, \t") && !strings.HasSuffix(raw, ".x") && !strings.HasSuffix(raw, ".X")
+}
+
+// parseVersionTerm expands one term of a versionConstraint into the comparisons it implies.
+func parseVersionTerm(term string) ([]versionComparison, error) {
+	raw := strings.TrimLeft(term, "<>=!^~")
+	op := term[:len(term)-len(raw)]
+
+	wildcard := false
+	for _, suffix := range []string{".x", ".X", ".*"} {
+		if strings.HasSuffix(raw, suffix) {
+			raw, wildcard = strings.TrimSuffix(raw, suffix), true
+			break
+		}
+	}
+
+	v, parts, err := parseDataVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// upper finds the first version beyond those sharing the first n components of v.
+	upper := func(n int) dataVersion {
+		if n <= 1 {
+			return dataVersion{major: v.major + 1}
+		}
+		return dataVersion{major: v.major, minor: v.minor + 1}
+	}
+
+	switch op {
+	case "", "=":
+		if !wildcard {
+			return []versionComparison{{"=", v}}, nil
+		}
+		return []versionComparison{{">=", v}, {"<", upper(parts)}}, nil
+	case "^":
+		if v.major == 0 && parts > 1 {
+			return []versionComparison{{">=", v}, {"<", upper(2)}}, nil
+		}
+		return []versionComparison{{">=", v}, {"<", upper(1)}}, nil
+	case "~":
+		return []versionComparison{{">=", v}, {"<", upper(parts)}}, nil
+	case "<", "<=", ">", ">=", "!=":
+		if wildcard {
+			return nil, fmt.Errorf("%q may not combine an operator with a wildcard", term)
+		}
+		return []versionComparison{{op, v}}, nil
+	default:
+		return nil, fmt.Errorf("%q has an unknown operator %q", term, op)
+	}
+}
+
+// allows determines whether a DataVersion satisfies this constraint.
+func (vc *versionConstraint) allows(version string) bool {
+	if version == vc.raw {
+		return true
+	}
+
+	for _, alternative := range vc.alternatives {
+		if len(alternative) == 0 {
+			return true
+		}
+	}
+
+	v, _, err := parseDataVersion(version)
+	if err != nil {
+		return false
+	}
+
+	for _, alternative := range vc.alternatives {
+		satisfied := true
+		for _, comparison := range alternative {
+			if !comparison.allows(v) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// splitDataVersion separates an Event Type written as "Contoso.Order.Created@2.0" into the
+// Event Type and the DataVersion constraint following the "@".
+func splitDataVersion(eventType string) (string, string) {
+	if i := strings.LastIndex(eventType, "@"); i >= 0 {
+		return eventType[:i], eventType[i+1:]
+	}
+	return eventType, ""
+}
+
+// WithDataVersion restricts the EventHandler being bound to Events whose DataVersion satisfies
+// a constraint, like "2.0", ">=1.1 <2", or "^1". See `TypeDispatchSubscriber.Bind` for how
+// it may be written as part of the Event Type instead. WithDataVersion panics if the
+// constraint is invalid.
+func WithDataVersion(constraint string) BindOption {
+	parsed, err := parseVersionConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(b *binding) {
+		b.version = parsed
+	}
+}
@@ -0,0 +1,226 @@
+package eventgrid_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func ExampleTypeDispatchSubscriber_Upcast() {
+	type OrderV1 struct {
+		Name string `json:"name"`
+	}
+
+	type OrderV2 struct {
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	}
+
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Upcast("Contoso.Order.Created@1.x", eventgrid.UpcastData("2.0", func(old *OrderV1) (*OrderV2, error) {
+			names := strings.SplitN(old.Name, " ", 2)
+			return &OrderV2{FirstName: names[0], LastName: names[len(names)-1]}, nil
+		})).
+		BindTyped("Contoso.Order.Created@2.x", func(c buffalo.Context, e eventgrid.Event, order *OrderV2) error {
+			fmt.Println(e.ID, e.DataVersion, order.LastName)
+			return nil
+		})
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[
+		{"id": "1", "eventType": "Contoso.Order.Created", "dataVersion": "1.0", "data": {"name": "Ada Lovelace"}},
+		{"id": "2", "eventType": "Contoso.Order.Created", "dataVersion": "2.0", "data": {"firstName": "Grace", "lastName": "Hopper"}}
+	]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if err = subscriber.Receive(NewMockContext(req)); err != nil {
+		fmt.Println(err)
+	}
+
+	// Unordered output:
+	// 1 2.0 Lovelace
+	// 2 2.0 Hopper
+}
+
+func TestTypeDispatchSubscriber_Handler_DataVersion(t *testing.T) {
+	handlerFor := func(name string) eventgrid.EventHandler {
+		return func(c buffalo.Context, e eventgrid.Event) error {
+			return errors.New(name)
+		}
+	}
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Contoso.Order.Created", handlerFor("fallback")).
+		Bind("Contoso.Order.Created@1.0", handlerFor("v1.0")).
+		Bind("Contoso.Order.Created@^1.1", handlerFor("v1")).
+		Bind("Contoso.Order.Created@>= 2, <3 || 4.x", handlerFor("v2 or v4")).
+		Bind("Contoso.Order.Created@~5.1", handlerFor("v5.1")).
+		Bind("Contoso.Order.Created@beta", handlerFor("beta"))
+
+	testCases := []struct {
+		dataVersion string
+		want        string
+	}{
+		{"", "fallback"},
+		{"1", "v1.0"},
+		{"1.0", "v1.0"},
+		{"1.0.0", "v1.0"},
+		{"1.0.1", "fallback"},
+		{"1.1", "v1"},
+		{"1.9.3", "v1"},
+		{"2.0", "v2 or v4"},
+		{"2.5", "v2 or v4"},
+		{"3.0", "fallback"},
+		{"4.2", "v2 or v4"},
+		{"5.1.7", "v5.1"},
+		{"5.2", "fallback"},
+		{"beta", "beta"},
+		{"gamma", "fallback"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dataVersion, func(t *testing.T) {
+			handler, ok := subject.Handler("Contoso.Order.Created@" + tc.dataVersion)
+			if !ok {
+				t.Fatal("no handler was found")
+			}
+
+			if got := handler(nil, eventgrid.Event{}).Error(); got != tc.want {
+				t.Errorf("got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+
+	subject.Unbind("Contoso.Order.Created@1.0")
+	if handler, ok := subject.Handler("Contoso.Order.Created@1.0"); !ok {
+		t.Error("no handler was found after unbinding one version")
+	} else if got := handler(nil, eventgrid.Event{}).Error(); got != "fallback" {
+		t.Errorf("got: %q want: %q", got, "fallback")
+	}
+}
+
+func TestTypeDispatchSubscriber_Bind_InvalidDataVersion(t *testing.T) {
+	for _, constraint := range []string{"", ">=one", ">=1.x", "=>1", "^1.2.3.4", "1 ||", "1.x 2.y"} {
+		t.Run(constraint, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+
+			eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+				Bind("Contoso.Order.Created", func(c buffalo.Context, e eventgrid.Event) error {
+					return nil
+				}, eventgrid.WithDataVersion(constraint))
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_DataVersion(t *testing.T) {
+	type OrderV1 struct {
+		Total int `json:"total"`
+	}
+
+	type OrderV2 struct {
+		TotalCents int `json:"totalCents"`
+	}
+
+	type OrderV3 struct {
+		Total struct {
+			Cents    int    `json:"cents"`
+			Currency string `json:"currency"`
+		} `json:"total"`
+	}
+
+	var seen []string
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		SetDispatchStrategy(eventgrid.Sequential()).
+		Upcast("Contoso.Order.Created@2.x", eventgrid.UpcastData("3.0", func(old OrderV2) (OrderV3, error) {
+			var upcast OrderV3
+			upcast.Total.Cents, upcast.Total.Currency = old.TotalCents, "USD"
+			return upcast, nil
+		})).
+		Upcast("Contoso.Order.Created@1.x", eventgrid.UpcastData("2.0", func(old *OrderV1) (*OrderV2, error) {
+			if old.Total < 0 {
+				return nil, errors.New("total must not be negative")
+			}
+			return &OrderV2{TotalCents: old.Total * 100}, nil
+		})).
+		BindTyped("Contoso.Order.Created@3.x", func(c buffalo.Context, e eventgrid.Event, order *OrderV3) error {
+			seen = append(seen, fmt.Sprintf("%s:%s:%d%s", e.ID, e.DataVersion, order.Total.Cents, order.Total.Currency))
+			return nil
+		})
+
+	body := `[
+		{"id": "1", "eventType": "Contoso.Order.Created", "dataVersion": "1.0", "data": {"total": 3}},
+		{"id": "2", "eventType": "Contoso.Order.Created", "dataVersion": "2.1", "data": {"totalCents": 450}},
+		{"id": "3", "eventType": "Contoso.Order.Created", "dataVersion": "3.0", "data": {"total": {"cents": 99, "currency": "EUR"}}},
+		{"id": "4", "eventType": "Contoso.Order.Created", "dataVersion": "1.0", "data": {"total": -1}},
+		{"id": "5", "eventType": "Contoso.Order.Created", "dataVersion": "1.0", "data": {"total": "three"}},
+		{"id": "6", "eventType": "Contoso.Order.Created", "dataVersion": "4.0", "data": {}}
+	]`
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	ctx := NewMockContext(req)
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if got, want := strings.Join(seen, ","), "1:3.0:300USD,2:3.0:450USD,3:3.0:99EUR"; got != want {
+		t.Errorf("got: %q want: %q", got, want)
+	}
+
+	result, ok := eventgrid.BatchResultFromContext(ctx)
+	if !ok {
+		t.Fatal("no BatchResult was associated with the Context")
+	}
+
+	for _, id := range []string{"4", "5", "6"} {
+		if r, ok := result.Get(id); !ok || r.Status != http.StatusBadRequest {
+			t.Errorf("event %q got result: %+v want status: %d", id, r, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestTypeRegistry_Lookup_DataVersionConstraint(t *testing.T) {
+	type v1 struct{}
+	type v2 struct{}
+	type latest struct{}
+
+	var subject eventgrid.TypeRegistry
+	subject.Register("Contoso.Order.Created", "", latest{}).
+		Register("Contoso.Order.Created", "^1", v1{}).
+		Register("Contoso.Order.Created", "2.0", v2{})
+
+	testCases := map[string]string{
+		"1":     "v1",
+		"1.4.2": "v1",
+		"2":     "v2",
+		"2.0":   "v2",
+		"2.1":   "latest",
+		"":      "latest",
+	}
+
+	for dataVersion, want := range testCases {
+		got, ok := subject.Lookup("Contoso.Order.Created", dataVersion)
+		if !ok {
+			t.Errorf("no type found for %q", dataVersion)
+			continue
+		}
+		if got.Name() != want {
+			t.Errorf("%q got: %v want: %s", dataVersion, got, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package eventgrid
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetter holds an Event that could not be processed, along with the reason why.
+type DeadLetter struct {
+	Event  Event       `json:"event"`
+	Result EventResult `json:"result"`
+
+	// DeliveryCount is the number of times Event Grid had already attempted to deliver the
+	// Event before the attempt which failed. See `DeliveryInfo`.
+	DeliveryCount int `json:"deliveryCount"`
+
+	// ReceivedAt is when the attempt which failed was received.
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// DeadLetterSink receives Events that could not be processed, so that they may be
+// inspected later instead of being delivered again by Event Grid.
+type DeadLetterSink interface {
+	Send(context.Context, DeadLetter) error
+}
+
+// DeadLetterSinkFunc allows a plain function to be used as a `DeadLetterSink`.
+type DeadLetterSinkFunc func(context.Context, DeadLetter) error
+
+// Send calls f(ctx, letter).
+func (f DeadLetterSinkFunc) Send(ctx context.Context, letter DeadLetter) error {
+	return f(ctx, letter)
+}
+
+// ErrorClassifier decides whether an Event that failed to be processed is worth delivering
+// again, or whether it will never be processed successfully.
+type ErrorClassifier interface {
+	Permanent(EventResult) bool
+}
+
+// ErrorClassifierFunc allows a plain function to be used as an `ErrorClassifier`.
+type ErrorClassifierFunc func(EventResult) bool
+
+// Permanent calls f(result).
+func (f ErrorClassifierFunc) Permanent(result EventResult) bool {
+	return f(result)
+}
+
+// DefaultErrorClassifier considers a failure permanent when its error was marked using
+// `Permanent`, when its error came from reading malformed JSON, or when it was reported with
+// one of the HTTP Status Codes which Event Grid itself does not retry: 400, 403, or 413.
+var DefaultErrorClassifier ErrorClassifier = ErrorClassifierFunc(func(result EventResult) bool {
+	if IsPermanent(result.Err) {
+		return true
+	}
+
+	for err := result.Err; err != nil; {
+		switch err.(type) {
+		case *json.SyntaxError, *json.UnmarshalTypeError:
+			return true
+		}
+
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+
+	switch result.Status {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusRequestEntityTooLarge:
+		return true
+	}
+	return false
+})
+
+// causer is implemented by errors which wrap another, like those created by
+// "github.com/pkg/errors".
+type causer interface {
+	Cause() error
+}
+
+// unwrap fetches the error wrapped by another, whether it was wrapped using
+// "github.com/pkg/errors" or using `fmt.Errorf` with "%w".
+func unwrap(err error) error {
+	switch wrapper := err.(type) {
+	case causer:
+		return wrapper.Cause()
+	case interface{ Unwrap() error }:
+		return wrapper.Unwrap()
+	}
+	return nil
+}
+
+type permanentError struct {
+	error
+}
+
+func (err permanentError) Cause() error {
+	return err.error
+}
+
+func (permanentError) Permanent() bool {
+	return true
+}
+
+// Permanent marks an error as one that will not be resolved by delivering the Event again.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent determines whether an error, or any error it wraps, was marked using `Permanent`.
+func IsPermanent(err error) bool {
+	for err != nil {
+		if p, ok := err.(interface{ Permanent() bool }); ok && p.Permanent() {
+			return true
+		}
+
+		c, ok := err.(causer)
+		if !ok {
+			return false
+		}
+		err = c.Cause()
+	}
+	return false
+}
+
+// FileDeadLetterSink is a DeadLetterSink which appends each DeadLetter it is sent to a file,
+// as a single line of JSON. The file is created if it does not already exist.
+type FileDeadLetterSink struct {
+	Path string
+	lock sync.Mutex
+}
+
+// NewFileDeadLetterSink creates a FileDeadLetterSink which writes to the file at path.
+func NewFileDeadLetterSink(path string) *FileDeadLetterSink {
+	return &FileDeadLetterSink{Path: path}
+}
+
+// Send appends a DeadLetter to the file.
+func (s *FileDeadLetterSink) Send(_ context.Context, letter DeadLetter) error {
+	serialized, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	serialized = append(serialized, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	handle, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = handle.Write(serialized); err != nil {
+		handle.Close()
+		return err
+	}
+	return handle.Close()
+}
+
+// ReadDeadLetters reads each DeadLetter that was written by a `FileDeadLetterSink`.
+func ReadDeadLetters(r io.Reader) (letters []DeadLetter, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxPayloadSize*2)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var letter DeadLetter
+		if err = json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return
+		}
+		letters = append(letters, letter)
+	}
+	err = scanner.Err()
+	return
+}
@@ -0,0 +1,134 @@
+package eventgrid_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	var payload struct{ Size int }
+	unmarshalErr := json.Unmarshal([]byte(`{"Size": "large"}`), &payload)
+
+	testCases := []struct {
+		name   string
+		result eventgrid.EventResult
+		want   bool
+	}{
+		{"bad-request", eventgrid.EventResult{Status: http.StatusBadRequest}, true},
+		{"forbidden", eventgrid.EventResult{Status: http.StatusForbidden}, true},
+		{"too-large", eventgrid.EventResult{Status: http.StatusRequestEntityTooLarge}, true},
+		{"internal", eventgrid.EventResult{Status: http.StatusInternalServerError, Err: errors.New("database unavailable")}, false},
+		{"timeout", eventgrid.EventResult{Status: http.StatusGatewayTimeout}, false},
+		{"throttled", eventgrid.EventResult{Status: http.StatusTooManyRequests}, false},
+		{"marked", eventgrid.EventResult{Status: http.StatusInternalServerError, Err: eventgrid.Permanent(errors.New("unknown tenant"))}, true},
+		{"marked-wrapped", eventgrid.EventResult{Status: http.StatusInternalServerError, Err: pkgerrors.Wrap(eventgrid.Permanent(errors.New("unknown tenant")), "handling")}, true},
+		{"unmarshal", eventgrid.EventResult{Status: http.StatusInternalServerError, Err: pkgerrors.WithStack(unmarshalErr)}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := eventgrid.DefaultErrorClassifier.Permanent(tc.result); got != tc.want {
+				t.Errorf("got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+
+	if eventgrid.Permanent(nil) != nil {
+		t.Error("marking a nil error should leave it nil")
+	}
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+
+	subject := newOutcomeSubscriber().
+		SetBatchPolicy(eventgrid.DeadLetterPermanentFailures).
+		SetDeadLetterSink(eventgrid.NewFileDeadLetterSink(path))
+
+	req := newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject", "Contoso.Reject")
+	req.Header.Set(eventgrid.DeliveryCountHeader, "3")
+	if got, want := receiveStatus(subject, NewMockContext(req)), http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	handle, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+
+	letters, err := eventgrid.ReadDeadLetters(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(letters), 2; got != want {
+		t.Fatalf("got %d dead letters want: %d", got, want)
+	}
+	for _, letter := range letters {
+		if letter.Event.EventType != "Contoso.Reject" {
+			t.Errorf("unexpected event %q was dead-lettered", letter.Event.EventType)
+		}
+		if letter.Result.Status != http.StatusBadRequest || letter.Result.Err == nil || letter.Result.Err.Error() != "rejected" {
+			t.Errorf("got result: %+v", letter.Result)
+		}
+		if letter.DeliveryCount != 3 {
+			t.Errorf("got delivery count: %d want: 3", letter.DeliveryCount)
+		}
+		if letter.ReceivedAt.IsZero() || len(letter.Event.Data) == 0 {
+			t.Errorf("dead letter is missing details: %+v", letter)
+		}
+	}
+}
+
+func TestDeadLetterPermanentFailures_Retryable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+
+	subject := newOutcomeSubscriber().
+		SetBatchPolicy(eventgrid.DeadLetterPermanentFailures).
+		SetDeadLetterSink(eventgrid.NewFileDeadLetterSink(path))
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Reject", "Contoso.Crash"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("nothing should be dead-lettered while a failure is retryable: %v", err)
+	}
+
+	subject.SetErrorClassifier(eventgrid.ErrorClassifierFunc(func(eventgrid.EventResult) bool {
+		return true
+	}))
+	ctx = NewMockContext(newBatchRequest(t, "Contoso.Reject", "Contoso.Crash"))
+	if got, want := receiveStatus(subject, ctx), http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+}
+
+func TestEventResult_JSON(t *testing.T) {
+	original := eventgrid.EventResult{ID: "1", EventType: "Contoso.A", Status: http.StatusBadRequest, Err: errors.New("rejected")}
+
+	serialized, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got eventgrid.EventResult
+	if err = json.Unmarshal(serialized, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != original.ID || got.EventType != original.EventType || got.Status != original.Status || got.Err == nil || got.Err.Error() != original.Err.Error() {
+		t.Errorf("got: %+v want: %+v", got, original)
+	}
+}
+
+var _ eventgrid.DeadLetterSink = &eventgrid.FileDeadLetterSink{}
@@ -0,0 +1,175 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PayloadTooLargeError is reported when the body of a request is larger than `MaxPayloadSize`.
+type PayloadTooLargeError struct {
+	Limit int64
+}
+
+func (err PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds the limit of %d bytes", err.Limit)
+}
+
+// EventTooLargeError is reported when an individual Event in a request is larger than
+// `MaxEventSize`.
+type EventTooLargeError struct {
+	Index int
+	Size  int
+	Limit int
+}
+
+func (err EventTooLargeError) Error() string {
+	return fmt.Sprintf("event %d is %d bytes, which exceeds the limit of %d", err.Index, err.Size, err.Limit)
+}
+
+// EventDecoder reads a batch of Events from the body of a request one at a time, enforcing
+// size limits as it goes: at most `MaxPayloadSize` bytes are read from the body, and no Event
+// may be larger than `MaxEventSize`. It holds only the Event being read, but a caller which
+// keeps every Event it decodes, as `TypeDispatchSubscriber` does, still holds the whole batch.
+type EventDecoder struct {
+	dec    *json.Decoder
+	opened bool
+	index  int
+	err    error
+}
+
+// NewEventDecoder creates an EventDecoder which reads a JSON array of Events from `r`.
+func NewEventDecoder(r io.Reader) *EventDecoder {
+	return &EventDecoder{
+		dec: json.NewDecoder(newPayloadReader(r, MaxPayloadSize)),
+	}
+}
+
+// Decode reads the next Event in the batch. Once every Event has been read, `io.EOF` is
+// returned. An Event which is too large is still read, so that it may be identified, but is
+// reported with an `EventTooLargeError`. Decoding may continue with the next Event after that,
+// whereas any other error ends the batch.
+func (d *EventDecoder) Decode(e *Event) error {
+	return d.decode(e)
+}
+
+func (d *EventDecoder) decode(v interface{}) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if !d.opened {
+		token, err := d.dec.Token()
+		if err == io.EOF {
+			return d.fail(errors.New("request body is empty"))
+		} else if err != nil {
+			return d.fail(err)
+		}
+
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return d.fail(fmt.Errorf("expected a JSON array of events, got: %v", token))
+		}
+		d.opened = true
+	}
+
+	if !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return d.fail(err)
+		}
+		return d.fail(io.EOF)
+	}
+
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return d.fail(err)
+	}
+
+	index := d.index
+	d.index++
+
+	if err := checkEventSize(index, raw); err != nil {
+		json.Unmarshal(raw, v)
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// fail records an error which ends the batch, so that it is reported by every later call.
+func (d *EventDecoder) fail(err error) error {
+	d.err = err
+	return err
+}
+
+// readEventBatch reads every Event in a JSON array of Events using `next`, which should
+// behave like `EventDecoder.Decode`, and holds all of them in memory. Events which are too
+// large are returned along with the others, and the error describing each is recorded by its
+// index in the batch.
+func readEventBatch(next func(*Event) error) ([]Event, map[int]error, error) {
+	var events []Event
+	var oversized map[int]error
+	for {
+		var e Event
+		err := next(&e)
+		if tooLarge, ok := err.(EventTooLargeError); ok {
+			if oversized == nil {
+				oversized = map[int]error{}
+			}
+			oversized[tooLarge.Index] = tooLarge
+		} else if err == io.EOF {
+			return events, oversized, nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, e)
+	}
+}
+
+func checkEventSize(index int, raw []byte) error {
+	if size := len(raw); size > MaxEventSize {
+		return EventTooLargeError{Index: index, Size: size, Limit: MaxEventSize}
+	}
+	return nil
+}
+
+// readStatus chooses the HTTP status code used to report an error encountered while reading
+// the Events in a request.
+func readStatus(err error) int {
+	switch err.(type) {
+	case PayloadTooLargeError, EventTooLargeError:
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// payloadReader reads at most `limit` bytes, and reports a `PayloadTooLargeError` rather than
+// silently truncating anything more than that.
+type payloadReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func newPayloadReader(r io.Reader, limit int64) *payloadReader {
+	return &payloadReader{r: r, limit: limit, remaining: limit}
+}
+
+func (p *payloadReader) Read(b []byte) (int, error) {
+	if p.remaining <= 0 {
+		var probe [1]byte
+		n, err := p.r.Read(probe[:])
+		if n > 0 {
+			return 0, PayloadTooLargeError{Limit: p.limit}
+		}
+		return 0, err
+	}
+
+	if int64(len(b)) > p.remaining {
+		b = b[:p.remaining]
+	}
+	n, err := p.r.Read(b)
+	p.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,201 @@
+package eventgrid_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func ExampleEventDecoder() {
+	dec := eventgrid.NewEventDecoder(strings.NewReader(`[
+		{"id": "1", "eventType": "Contoso.Order.Created"},
+		{"id": "2", "eventType": "Contoso.Order.Shipped"}
+	]`))
+
+	for {
+		var e eventgrid.Event
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(e.ID, e.EventType)
+	}
+
+	// Output:
+	// 1 Contoso.Order.Created
+	// 2 Contoso.Order.Shipped
+}
+
+func TestEventDecoder_Decode(t *testing.T) {
+	oversized := fmt.Sprintf(`{"id": "large", "data": %q}`, strings.Repeat("a", eventgrid.MaxEventSize))
+	dec := eventgrid.NewEventDecoder(strings.NewReader(`[{"id": "small"}, ` + oversized + `, {"id": "last"}]`))
+
+	var e eventgrid.Event
+	if err := dec.Decode(&e); err != nil || e.ID != "small" {
+		t.Fatalf("got: %q, %v want: %q", e.ID, err, "small")
+	}
+
+	e = eventgrid.Event{}
+	err := dec.Decode(&e)
+	tooLarge, ok := err.(eventgrid.EventTooLargeError)
+	if !ok {
+		t.Fatalf("got error %T want: eventgrid.EventTooLargeError", err)
+	}
+	if tooLarge.Index != 1 || tooLarge.Limit != eventgrid.MaxEventSize {
+		t.Errorf("got: %+v", tooLarge)
+	}
+	if e.ID != "large" {
+		t.Errorf("oversized event got id: %q want: %q", e.ID, "large")
+	}
+
+	if err = dec.Decode(&e); err != nil || e.ID != "last" {
+		t.Fatalf("got: %q, %v want: %q", e.ID, err, "last")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = dec.Decode(&e); err != io.EOF {
+			t.Errorf("got error: %v want: %v", err, io.EOF)
+		}
+	}
+}
+
+func TestEventDecoder_Decode_PayloadTooLarge(t *testing.T) {
+	events := make([]string, 0, 2*eventgrid.MaxPayloadSize/eventgrid.MaxEventSize)
+	for i := 0; i < cap(events); i++ {
+		events = append(events, fmt.Sprintf(`{"id": "%d", "data": %q}`, i, strings.Repeat("a", eventgrid.MaxEventSize/2)))
+	}
+	dec := eventgrid.NewEventDecoder(strings.NewReader("[" + strings.Join(events, ",") + "]"))
+
+	decoded := 0
+	for {
+		var e eventgrid.Event
+		err := dec.Decode(&e)
+		if err == nil {
+			decoded++
+			continue
+		}
+
+		if _, ok := err.(eventgrid.PayloadTooLargeError); !ok {
+			t.Fatalf("got error %T (%v) want: eventgrid.PayloadTooLargeError", err, err)
+		}
+		break
+	}
+
+	if decoded == 0 || decoded >= len(events) {
+		t.Errorf("decoded %d of %d events before reaching the limit", decoded, len(events))
+	}
+}
+
+func TestEventDecoder_Decode_Malformed(t *testing.T) {
+	for _, body := range []string{``, `{"id": "1"}`, `[{"id": "1"},`, `[{"id": 1}]`} {
+		t.Run(body, func(t *testing.T) {
+			dec := eventgrid.NewEventDecoder(strings.NewReader(body))
+
+			var err error
+			for err == nil {
+				var e eventgrid.Event
+				err = dec.Decode(&e)
+			}
+
+			if err == io.EOF {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_PayloadTooLarge(t *testing.T) {
+	handled := 0
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			handled++
+			return nil
+		})
+
+	body := fmt.Sprintf(`[{"id": "1", "eventType": "Contoso.A", "data": %q}]`, strings.Repeat("a", eventgrid.MaxPayloadSize))
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if got, want := receiveStatus(subject, NewMockContext(req)), http.StatusRequestEntityTooLarge; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if handled != 0 {
+		t.Errorf("handled %d events from a request which was too large", handled)
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_EventTooLarge(t *testing.T) {
+	handled := make(chan string, 2)
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			handled <- e.ID
+			return nil
+		})
+
+	for _, contentType := range []string{"application/json", eventgrid.CloudEventsBatchContentType} {
+		t.Run(contentType, func(t *testing.T) {
+			body := fmt.Sprintf(`[
+				{"id": "small", "eventType": "Contoso.A", "type": "Contoso.A", "specversion": "1.0", "data": "hi"},
+				{"id": "large", "eventType": "Contoso.A", "type": "Contoso.A", "specversion": "1.0", "data": %q}
+			]`, strings.Repeat("a", eventgrid.MaxEventSize))
+			req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", contentType)
+
+			ctx := NewMockContext(req)
+			if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+				t.Errorf("got status: %d want: %d", got, want)
+			}
+
+			if got := <-handled; got != "small" {
+				t.Errorf("got handled event: %q want: %q", got, "small")
+			}
+
+			result, ok := eventgrid.BatchResultFromContext(ctx)
+			if !ok {
+				t.Fatal("no BatchResult was associated with the Context")
+			}
+			if r, _ := result.Get("large"); r.Status != http.StatusRequestEntityTooLarge {
+				t.Errorf("large event got status: %d want: %d", r.Status, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+
+	close(handled)
+	for id := range handled {
+		t.Errorf("unexpectedly handled event %q", id)
+	}
+}
+
+func TestSubscriptionValidationMiddleware_PayloadTooLarge(t *testing.T) {
+	subject := eventgrid.SubscriptionValidationMiddleware(func(c buffalo.Context) error {
+		t.Error("request for validation was handed to the next Handler")
+		return nil
+	})
+
+	body := fmt.Sprintf(`[{"id": "1", "eventType": "Microsoft.EventGrid.SubscriptionValidationEvent", "data": {"padding": %q}}]`, strings.Repeat("a", eventgrid.MaxPayloadSize))
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Aeg-Event-Type", "SubscriptionValidation")
+	req.Header.Add("Content-Type", "application/json")
+
+	err = subject(NewMockContext(req))
+	if httpErr, ok := err.(buffalo.HTTPError); !ok || httpErr.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("got error: %v want status: %d", err, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,173 @@
+package eventgrid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// DedupClaimTTL is the longest amount of time a claim made using `DedupStore.Claim` lasts,
+// so that an Event claimed by an instance of an application which stopped before it could
+// complete or release its claim is eventually processed again.
+const DedupClaimTTL = time.Minute
+
+// DedupStore remembers which Events have already been processed, so that an Event delivered
+// more than once by an Event Grid Topic is only handled once.
+type DedupStore interface {
+	// Seen reports whether processing of the Event identified by key was already completed.
+	Seen(ctx context.Context, key string) (bool, error)
+
+	// Claim atomically reserves the Event identified by key for processing by the caller,
+	// and reports whether it did. An Event can not be claimed while processing of it was
+	// already completed, or while another claim on it has not been completed, released, or
+	// outlived `DedupClaimTTL`.
+	Claim(ctx context.Context, key string) (bool, error)
+
+	// Release gives up a claim on the Event identified by key, without recording that it
+	// was processed, so that it may be claimed again.
+	Release(ctx context.Context, key string) error
+
+	// Complete records that the Event identified by key has been processed. Implementations
+	// should forget about it once their TTL has elapsed.
+	Complete(ctx context.Context, key string) error
+}
+
+// MemoryDedupStore is a DedupStore which remembers processed Events in memory, using a
+// `Cache`. It is suitable for applications which are run as a single instance. The zero
+// value remembers Events for `CacheDefaultTTL`.
+//
+// Unlike a Cache, it is not limited to `CacheDefaultMaxDepth` Events: every processed Event
+// is remembered until its TTL has elapsed, so that no Event is ever forgotten early and
+// processed twice. The memory it uses grows with the number of Events processed within a TTL.
+type MemoryDedupStore struct {
+	cache     Cache
+	unbounded sync.Once
+	lock      sync.Mutex
+	claims    map[string]time.Time
+}
+
+// NewMemoryDedupStore creates a MemoryDedupStore which remembers each processed Event for
+// the provided length of time.
+func NewMemoryDedupStore(ttl time.Duration) *MemoryDedupStore {
+	created := &MemoryDedupStore{}
+	created.cache.SetTTL(ttl)
+	return created
+}
+
+// Seen reports whether the Event identified by key was processed within the TTL of this store.
+func (s *MemoryDedupStore) Seen(_ context.Context, key string) (bool, error) {
+	_, ok := s.cache.Get(key)
+	return ok, nil
+}
+
+// Claim reserves the Event identified by key for processing by the caller, unless it was
+// already processed or is reserved by someone else.
+func (s *MemoryDedupStore) Claim(_ context.Context, key string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.cache.Get(key); ok {
+		return false, nil
+	}
+
+	now := time.Now()
+	if expires, ok := s.claims[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+
+	if s.claims == nil {
+		s.claims = make(map[string]time.Time)
+	}
+	s.claims[key] = now.Add(DedupClaimTTL)
+	return true, nil
+}
+
+// Release gives up a claim on the Event identified by key.
+func (s *MemoryDedupStore) Release(_ context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.claims, key)
+	return nil
+}
+
+// Complete records that the Event identified by key has been processed.
+func (s *MemoryDedupStore) Complete(_ context.Context, key string) error {
+	s.unbounded.Do(func() {
+		s.cache.SetMaxDepth(^uint(0))
+	})
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.cache.Add(Event{ID: key})
+	delete(s.claims, key)
+	return nil
+}
+
+// dedupKey identifies an Event across deliveries. Event IDs are only unique within the
+// Topic which published them, so both are used.
+func dedupKey(e Event) string {
+	return e.Topic + "#" + e.ID
+}
+
+// IdempotencyMiddleware creates an EventMiddleware which hands each Event to the next
+// EventHandler only once. Each Event is claimed in the provided DedupStore before it is
+// processed. Once it has been processed successfully it is recorded as complete, and later
+// deliveries of an Event with the same Topic and ID are answered with an HTTP 200 without
+// being processed again. Should it fail to be processed, the claim is released so that the
+// Event is processed again when it is delivered again.
+//
+// A delivery of an Event which is claimed but not yet complete, because another delivery of
+// it is being processed concurrently, is recorded using `RetryLater` so that it is delivered
+// again once that processing is over. Should the DedupStore be unavailable while claiming an
+// Event, an HTTP 500 is recorded so that it is delivered again as well.
+func IdempotencyMiddleware(store DedupStore) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(c buffalo.Context, e Event) error {
+			key := dedupKey(e)
+
+			claimed, err := store.Claim(c, key)
+			if err != nil {
+				return c.Error(http.StatusInternalServerError, fmt.Errorf("unable to determine whether event %q is a duplicate: %v", e.ID, err))
+			}
+
+			if !claimed {
+				seen, err := store.Seen(c, key)
+				if err != nil {
+					return c.Error(http.StatusInternalServerError, fmt.Errorf("unable to determine whether event %q is a duplicate: %v", e.ID, err))
+				}
+				if !seen {
+					return RetryLater(c, fmt.Errorf("event %q is already being processed", e.ID))
+				}
+
+				if logger := c.Logger(); logger != nil {
+					logger.Debugf("skipping event %q, which has already been processed", e.ID)
+				}
+				c.Response().WriteHeader(http.StatusOK)
+				return nil
+			}
+
+			err = next(c, e)
+			if result, ok := recordedResult(c); err != nil || (ok && !result.Succeeded()) {
+				if releaseErr := store.Release(c, key); releaseErr != nil {
+					if logger := c.Logger(); logger != nil {
+						logger.Errorf("unable to release the claim on event %q: %v", e.ID, releaseErr)
+					}
+				}
+				return err
+			}
+
+			if err = store.Complete(c, key); err != nil {
+				if logger := c.Logger(); logger != nil {
+					logger.Errorf("unable to record that event %q was processed: %v", e.ID, err)
+				}
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package eventgrid_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func ExampleIdempotencyMiddleware() {
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(eventgrid.IdempotencyMiddleware(eventgrid.NewMemoryDedupStore(time.Hour))).
+		Bind("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event) error {
+			fmt.Println("processing", e.ID)
+			return nil
+		})
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{"id": "1", "topic": "/contoso", "eventType": "Microsoft.Storage.BlobCreated"}]`))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		req.Header.Add("Content-Type", "application/json")
+
+		ctx := NewMockContext(req)
+		if err = subscriber.Receive(ctx); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("answered", ctx.Status())
+	}
+
+	// Output:
+	// processing 1
+	// answered 200
+	// answered 200
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	var handled int32
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(eventgrid.IdempotencyMiddleware(&eventgrid.MemoryDedupStore{})).
+		Bind("Contoso.Succeed", func(c buffalo.Context, e eventgrid.Event) error {
+			atomic.AddInt32(&handled, 1)
+			return nil
+		}).
+		Bind("Contoso.Reject", func(c buffalo.Context, e eventgrid.Event) error {
+			atomic.AddInt32(&handled, 1)
+			return c.Error(http.StatusBadRequest, errors.New("rejected"))
+		})
+
+	for i := 0; i < 3; i++ {
+		receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject")))
+	}
+
+	// The successful Event is handled once, the failing one is handled on each delivery.
+	if got, want := atomic.LoadInt32(&handled), int32(4); got != want {
+		t.Errorf("got %d events handled want: %d", got, want)
+	}
+}
+
+func TestIdempotencyMiddleware_Topics(t *testing.T) {
+	var handled int32
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(eventgrid.IdempotencyMiddleware(&eventgrid.MemoryDedupStore{})).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			atomic.AddInt32(&handled, 1)
+			return nil
+		})
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{"id": "1", "topic": "/a", "eventType": "Contoso.A"}, {"id": "1", "topic": "/b", "eventType": "Contoso.A"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	receiveStatus(subject, NewMockContext(req))
+
+	if got, want := atomic.LoadInt32(&handled), int32(2); got != want {
+		t.Errorf("got %d events handled want: %d", got, want)
+	}
+}
+
+type failingDedupStore struct{}
+
+func (failingDedupStore) Seen(context.Context, string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingDedupStore) Claim(context.Context, string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingDedupStore) Release(context.Context, string) error {
+	return errors.New("connection refused")
+}
+
+func (failingDedupStore) Complete(context.Context, string) error {
+	return errors.New("connection refused")
+}
+
+func TestIdempotencyMiddleware_Concurrent(t *testing.T) {
+	var handled int32
+	started, release := make(chan struct{}), make(chan struct{})
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(eventgrid.IdempotencyMiddleware(&eventgrid.MemoryDedupStore{})).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			atomic.AddInt32(&handled, 1)
+			close(started)
+			<-release
+			return nil
+		})
+
+	first := make(chan int)
+	go func() {
+		first <- receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.A")))
+	}()
+	<-started
+
+	// A delivery of the Event while it is being processed is asked to be delivered again.
+	if got, want := receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.A"))), http.StatusServiceUnavailable; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	close(release)
+	if got, want := <-first, http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if got, want := receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.A"))), http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	if got := atomic.LoadInt32(&handled); got != 1 {
+		t.Errorf("got %d events handled want: 1", got)
+	}
+}
+
+func TestIdempotencyMiddleware_StoreFailure(t *testing.T) {
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(eventgrid.IdempotencyMiddleware(failingDedupStore{})).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			t.Error("event should not have been handled")
+			return nil
+		})
+
+	if got, want := receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.A"))), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+}
+
+func TestMemoryDedupStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	subject := eventgrid.NewMemoryDedupStore(10 * time.Millisecond)
+
+	if err := subject.Complete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if seen, _ := subject.Seen(ctx, "1"); !seen {
+		t.Error("completed key was not seen")
+	}
+	if seen, _ := subject.Seen(ctx, "2"); seen {
+		t.Error("unknown key was seen")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if seen, _ := subject.Seen(ctx, "1"); seen {
+		t.Error("expired key was seen")
+	}
+}
+
+func TestMemoryDedupStore_Claim(t *testing.T) {
+	ctx := context.Background()
+	subject := &eventgrid.MemoryDedupStore{}
+
+	if claimed, _ := subject.Claim(ctx, "1"); !claimed {
+		t.Fatal("unknown key was not claimed")
+	}
+	if claimed, _ := subject.Claim(ctx, "1"); claimed {
+		t.Error("claimed key was claimed again")
+	}
+
+	if err := subject.Release(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, _ := subject.Claim(ctx, "1"); !claimed {
+		t.Fatal("released key was not claimed")
+	}
+
+	if err := subject.Complete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, _ := subject.Claim(ctx, "1"); claimed {
+		t.Error("completed key was claimed")
+	}
+	if seen, _ := subject.Seen(ctx, "1"); !seen {
+		t.Error("completed key was not seen")
+	}
+}
+
+func TestMemoryDedupStore_Depth(t *testing.T) {
+	ctx := context.Background()
+	subject := &eventgrid.MemoryDedupStore{}
+
+	for i := uint(0); i <= eventgrid.CacheDefaultMaxDepth; i++ {
+		if err := subject.Complete(ctx, fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if seen, _ := subject.Seen(ctx, "0"); !seen {
+		t.Errorf("expected the first key to be remembered after %d more were completed", eventgrid.CacheDefaultMaxDepth)
+	}
+}
@@ -0,0 +1,174 @@
+package eventgrid
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// These constants name the HTTP headers Event Grid includes with each delivery.
+const (
+	DeliveryCountHeader    = "Aeg-Delivery-Count"
+	SubscriptionNameHeader = "Aeg-Subscription-Name"
+	DataVersionHeader      = "Aeg-Data-Version"
+	MetadataVersionHeader  = "Aeg-Metadata-Version"
+)
+
+// DeliveryInfo describes the attempt by Event Grid to deliver the request being processed.
+type DeliveryInfo struct {
+	// DeliveryCount is the number of times Event Grid had already attempted to deliver this
+	// request. It is zero for the first attempt.
+	DeliveryCount int
+
+	// SubscriptionName is the name of the Event Subscription which caused this delivery.
+	SubscriptionName string
+
+	// DataVersion and MetadataVersion describe the schema of the Events being delivered.
+	DataVersion     string
+	MetadataVersion string
+}
+
+// ParseDeliveryInfo reads the headers Event Grid includes with each delivery. Missing or
+// malformed headers are left as zero values.
+func ParseDeliveryInfo(req *http.Request) (info DeliveryInfo) {
+	if req == nil {
+		return
+	}
+
+	info.DeliveryCount, _ = strconv.Atoi(req.Header.Get(DeliveryCountHeader))
+	info.SubscriptionName = req.Header.Get(SubscriptionNameHeader)
+	info.DataVersion = req.Header.Get(DataVersionHeader)
+	info.MetadataVersion = req.Header.Get(MetadataVersionHeader)
+	return
+}
+
+// Attempt numbers the delivery being processed, starting at one.
+func (info DeliveryInfo) Attempt() int {
+	return info.DeliveryCount + 1
+}
+
+// IsRedelivery indicates whether Event Grid has attempted to deliver this request before.
+func (info DeliveryInfo) IsRedelivery() bool {
+	return info.DeliveryCount > 0
+}
+
+// DeliveryInfoFromContext reads the DeliveryInfo of the request being processed using a
+// particular `buffalo.Context`.
+func DeliveryInfoFromContext(c buffalo.Context) DeliveryInfo {
+	return ParseDeliveryInfo(c.Request())
+}
+
+// DeliveryInfo reads the DeliveryInfo of the request being processed using this Context.
+func (c *Context) DeliveryInfo() DeliveryInfo {
+	return DeliveryInfoFromContext(c)
+}
+
+// RetryAfterDefault is the amount of time suggested to Event Grid before delivering a batch
+// again, when one of its Events asked for that using `RetryLater`. Event Grid itself waits at
+// least 30 seconds before redelivering a request answered with an HTTP 503.
+const RetryAfterDefault = 30 * time.Second
+
+// RetryLater records that an Event could not be processed yet, but should be delivered again
+// later, for example because a resource it depends on is temporarily unavailable. The Event
+// is recorded with an HTTP 503. A `TypeDispatchSubscriber` then answers a failing batch with
+// an HTTP 503 and a "Retry-After" header, which Event Grid answers by waiting before it retries.
+func RetryLater(c buffalo.Context, reason error) error {
+	if reason == nil {
+		reason = fmt.Errorf("event should be delivered again later")
+	}
+	return c.Error(http.StatusServiceUnavailable, reason)
+}
+
+// RetryPolicy describes how an EventHandler should treat an Event which keeps failing as Event
+// Grid delivers it again and again.
+type RetryPolicy struct {
+	// MaxDeliveries is the number of deliveries after which a failing Event is given up on.
+	// An Event which is given up on is sent to DeadLetterSink, if there is one, and recorded as
+	// having been processed successfully so that Event Grid stops delivering it. When it is
+	// not positive, Events are never given up on.
+	MaxDeliveries int
+
+	// DeadLetterSink receives Events which are given up on.
+	DeadLetterSink DeadLetterSink
+
+	// EscalateAfter is the number of deliveries after which each failure of an Event is
+	// escalated, by calling Escalate. When it is not positive, failures are never escalated.
+	EscalateAfter int
+
+	// Escalate is called each time an Event fails after EscalateAfter deliveries. When it is
+	// nil, a warning is logged instead.
+	Escalate func(c buffalo.Context, e Event, info DeliveryInfo, result EventResult)
+}
+
+// RetryPolicyMiddleware creates an EventMiddleware which applies a RetryPolicy to each Event
+// that the next EventHandler fails to process.
+func RetryPolicyMiddleware(p RetryPolicy) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(c buffalo.Context, e Event) error {
+			err := next(c, e)
+
+			result := outcome(c, e, err)
+			if result.Succeeded() {
+				return err
+			}
+
+			info := DeliveryInfoFromContext(c)
+			if p.EscalateAfter > 0 && info.Attempt() >= p.EscalateAfter {
+				if p.Escalate != nil {
+					p.Escalate(c, e, info, result)
+				} else if logger := c.Logger(); logger != nil {
+					logger.Warnf("event %q has failed %d deliveries: %v", e.ID, info.Attempt(), result.Err)
+				}
+			}
+
+			if p.MaxDeliveries <= 0 || info.Attempt() < p.MaxDeliveries {
+				return err
+			}
+
+			if p.DeadLetterSink != nil {
+				letter := DeadLetter{
+					Event:         e,
+					Result:        result,
+					DeliveryCount: info.DeliveryCount,
+					ReceivedAt:    time.Now(),
+				}
+				if sendErr := p.DeadLetterSink.Send(c, letter); sendErr != nil {
+					if logger := c.Logger(); logger != nil {
+						logger.Errorf("unable to dead-letter event %q: %v", e.ID, sendErr)
+					}
+					return err
+				}
+			}
+
+			if logger := c.Logger(); logger != nil {
+				logger.Warnf("giving up on event %q after %d deliveries: %v", e.ID, info.Attempt(), result.Err)
+			}
+			if ctx, ok := c.(*Context); ok && ctx.event != nil {
+				ctx.resp.replace(ctx.index, *ctx.event, http.StatusOK, nil)
+			}
+			return nil
+		}
+	}
+}
+
+// outcome determines the result of an EventHandler having processed an Event, either from
+// what it recorded in its Context or from the error it returned.
+func outcome(c buffalo.Context, e Event, err error) EventResult {
+	result, ok := recordedResult(c)
+	if !ok {
+		result = EventResult{ID: e.ID, EventType: e.EventType, Status: errorStatus(err)}
+	}
+
+	if err != nil {
+		if result.Succeeded() {
+			result.Status = http.StatusInternalServerError
+		}
+		if result.Err == nil {
+			result.Err = err
+		}
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package eventgrid_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func ExampleDeliveryInfoFromContext() {
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			info := eventgrid.DeliveryInfoFromContext(c)
+			fmt.Printf("attempt %d from %s\n", info.Attempt(), info.SubscriptionName)
+			return nil
+		})
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{"id": "1", "eventType": "Contoso.Items.ItemReceived"}]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Aeg-Delivery-Count", "2")
+	req.Header.Add("Aeg-Subscription-Name", "ITEMS")
+
+	if err = subscriber.Receive(NewMockContext(req)); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output: attempt 3 from ITEMS
+}
+
+func TestParseDeliveryInfo(t *testing.T) {
+	req := newBatchRequest(t, "Contoso.A")
+	req.Header.Set(eventgrid.DeliveryCountHeader, "4")
+	req.Header.Set(eventgrid.SubscriptionNameHeader, "ITEMS")
+	req.Header.Set(eventgrid.DataVersionHeader, "2.0")
+	req.Header.Set(eventgrid.MetadataVersionHeader, "1")
+
+	want := eventgrid.DeliveryInfo{DeliveryCount: 4, SubscriptionName: "ITEMS", DataVersion: "2.0", MetadataVersion: "1"}
+	if got := eventgrid.ParseDeliveryInfo(req); got != want {
+		t.Errorf("got: %+v want: %+v", got, want)
+	}
+
+	if got := eventgrid.ParseDeliveryInfo(newBatchRequest(t, "Contoso.A")); got.IsRedelivery() || got.Attempt() != 1 {
+		t.Errorf("a request without headers should be the first attempt: %+v", got)
+	}
+
+	req.Header.Set(eventgrid.DeliveryCountHeader, "many")
+	if got := eventgrid.ParseDeliveryInfo(req); got.DeliveryCount != 0 {
+		t.Errorf("got delivery count: %d want: 0", got.DeliveryCount)
+	}
+}
+
+func TestRetryLater(t *testing.T) {
+	subject := newOutcomeSubscriber().
+		Bind("Contoso.Later", func(c buffalo.Context, e eventgrid.Event) error {
+			return eventgrid.RetryLater(c, errors.New("inventory service is unavailable"))
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Later"))
+	if got, want := receiveStatus(subject, ctx), http.StatusServiceUnavailable; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	if got := ctx.Response().Header().Get("Retry-After"); got != "30" {
+		t.Errorf("got Retry-After: %q want: %q", got, "30")
+	}
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	if r, _ := result.Get("1"); r.Status != http.StatusServiceUnavailable || eventgrid.DefaultErrorClassifier.Permanent(r) {
+		t.Errorf("got result: %+v", r)
+	}
+
+	ctx = NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Crash"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+}
+
+func TestRetryPolicyMiddleware(t *testing.T) {
+	var lock sync.Mutex
+	var deadLettered, escalated []int
+
+	policy := eventgrid.RetryPolicy{
+		MaxDeliveries: 3,
+		DeadLetterSink: eventgrid.DeadLetterSinkFunc(func(ctx context.Context, letter eventgrid.DeadLetter) error {
+			lock.Lock()
+			defer lock.Unlock()
+			deadLettered = append(deadLettered, letter.DeliveryCount)
+			return nil
+		}),
+		EscalateAfter: 2,
+		Escalate: func(c buffalo.Context, e eventgrid.Event, info eventgrid.DeliveryInfo, result eventgrid.EventResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			escalated = append(escalated, info.DeliveryCount)
+		},
+	}
+
+	subject := newOutcomeSubscriber().Use(eventgrid.RetryPolicyMiddleware(policy))
+
+	want := []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK}
+	for count, status := range want {
+		req := newBatchRequest(t, "Contoso.Succeed", "Contoso.Crash")
+		req.Header.Set(eventgrid.DeliveryCountHeader, fmt.Sprint(count))
+
+		ctx := NewMockContext(req)
+		if got := receiveStatus(subject, ctx); got != status {
+			t.Errorf("delivery %d got status: %d want: %d", count, got, status)
+		}
+	}
+
+	if got := fmt.Sprint(escalated); got != "[1 2]" {
+		t.Errorf("got escalations for deliveries: %s want: [1 2]", got)
+	}
+	if got := fmt.Sprint(deadLettered); got != "[2]" {
+		t.Errorf("got dead letters for deliveries: %s want: [2]", got)
+	}
+}
+
+func TestRetryPolicyMiddleware_DeadLetterFailure(t *testing.T) {
+	subject := newOutcomeSubscriber().Use(eventgrid.RetryPolicyMiddleware(eventgrid.RetryPolicy{
+		MaxDeliveries: 1,
+		DeadLetterSink: eventgrid.DeadLetterSinkFunc(func(context.Context, eventgrid.DeadLetter) error {
+			return errors.New("disk full")
+		}),
+	}))
+
+	if got, want := receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.Reject"))), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+}
+
+func TestRetryPolicyMiddleware_WrappedHTTPError(t *testing.T) {
+	notFound := buffalo.HTTPError{Status: http.StatusNotFound, Cause: errors.New("blob is gone")}
+
+	testCases := map[string]error{
+		"pkg/errors": pkgerrors.Wrap(notFound, "unable to read blob"),
+		"fmt.Errorf": fmt.Errorf("unable to read blob: %w", notFound),
+	}
+
+	for name, handlerErr := range testCases {
+		handlerErr := handlerErr
+		t.Run(name, func(t *testing.T) {
+			var got int
+			subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+				Use(eventgrid.RetryPolicyMiddleware(eventgrid.RetryPolicy{
+					EscalateAfter: 1,
+					Escalate: func(c buffalo.Context, e eventgrid.Event, info eventgrid.DeliveryInfo, result eventgrid.EventResult) {
+						got = result.Status
+					},
+				})).
+				Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+					return handlerErr
+				})
+
+			receiveStatus(subject, NewMockContext(newBatchRequest(t, "Contoso.A")))
+			if got != http.StatusNotFound {
+				t.Errorf("got status: %d want: %d", got, http.StatusNotFound)
+			}
+		})
+	}
+}
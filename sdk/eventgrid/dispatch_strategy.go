@@ -0,0 +1,142 @@
+package eventgrid
+
+import (
+	"sync"
+)
+
+// DispatchStrategy decides the order in which, and how many at a time, the Events in a batch
+// are processed by a `TypeDispatchSubscriber`.
+type DispatchStrategy interface {
+	// Dispatch calls process once for each Event, passing along its index in the batch. It
+	// must not return until every call to process has returned.
+	Dispatch(events []Event, process func(int, Event))
+}
+
+// DispatchStrategyFunc allows a plain function to be used as a `DispatchStrategy`.
+type DispatchStrategyFunc func([]Event, func(int, Event))
+
+// Dispatch calls f(events, process).
+func (f DispatchStrategyFunc) Dispatch(events []Event, process func(int, Event)) {
+	f(events, process)
+}
+
+// Concurrent creates a DispatchStrategy which processes every Event in a batch at the same
+// time, each in its own goroutine. This is the default strategy of a `TypeDispatchSubscriber`.
+func Concurrent() DispatchStrategy {
+	return DispatchStrategyFunc(func(events []Event, process func(int, Event)) {
+		var wg sync.WaitGroup
+		wg.Add(len(events))
+		for i, event := range events {
+			go func(i int, event Event) {
+				defer wg.Done()
+				process(i, event)
+			}(i, event)
+		}
+		wg.Wait()
+	})
+}
+
+// Sequential creates a DispatchStrategy which processes one Event at a time, in the order
+// they arrived.
+func Sequential() DispatchStrategy {
+	return DispatchStrategyFunc(func(events []Event, process func(int, Event)) {
+		for i, event := range events {
+			process(i, event)
+		}
+	})
+}
+
+// WorkerPool creates a DispatchStrategy which processes no more than size Events at a time.
+// Should size be less than one, a single worker is used.
+func WorkerPool(size int) DispatchStrategy {
+	if size < 1 {
+		size = 1
+	}
+
+	return DispatchStrategyFunc(func(events []Event, process func(int, Event)) {
+		indexes := make(chan int)
+
+		var wg sync.WaitGroup
+		workers := size
+		if workers > len(events) {
+			workers = len(events)
+		}
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for i := range indexes {
+					process(i, events[i])
+				}
+			}()
+		}
+
+		for i := range events {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+	})
+}
+
+// OrderedByKey creates a DispatchStrategy which processes Events that share a key one at a
+// time, in the order they arrived, while Events with different keys are processed in
+// parallel. No more than size keys are worked on at a time. Should size be less than one,
+// there is no limit.
+func OrderedByKey(key func(Event) string, size int) DispatchStrategy {
+	return DispatchStrategyFunc(func(events []Event, process func(int, Event)) {
+		var keys []string
+		groups := make(map[string][]int)
+		for i, event := range events {
+			k := key(event)
+			if _, ok := groups[k]; !ok {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], i)
+		}
+
+		processGroup := func(k string) {
+			for _, i := range groups[k] {
+				process(i, events[i])
+			}
+		}
+
+		var wg sync.WaitGroup
+		if size < 1 || size >= len(keys) {
+			wg.Add(len(keys))
+			for _, k := range keys {
+				go func(k string) {
+					defer wg.Done()
+					processGroup(k)
+				}(k)
+			}
+			wg.Wait()
+			return
+		}
+
+		pending := make(chan string)
+		wg.Add(size)
+		for w := 0; w < size; w++ {
+			go func() {
+				defer wg.Done()
+				for k := range pending {
+					processGroup(k)
+				}
+			}()
+		}
+
+		for _, k := range keys {
+			pending <- k
+		}
+		close(pending)
+		wg.Wait()
+	})
+}
+
+// OrderedBySubject creates a DispatchStrategy which processes Events that share a Subject in
+// the order they arrived, while Events with different Subjects are processed in parallel.
+func OrderedBySubject(size int) DispatchStrategy {
+	return OrderedByKey(func(e Event) string {
+		return e.Subject
+	}, size)
+}
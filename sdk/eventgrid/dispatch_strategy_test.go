@@ -0,0 +1,165 @@
+package eventgrid_test
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func newSubjectEvents(count, subjects int) []eventgrid.Event {
+	events := make([]eventgrid.Event, 0, count)
+	for i := 0; i < count; i++ {
+		events = append(events, eventgrid.Event{
+			ID:      fmt.Sprint(i),
+			Subject: fmt.Sprintf("/items/%d", i%subjects),
+		})
+	}
+	return events
+}
+
+// concurrencyTracker records the largest number of calls to enter that were active at once.
+type concurrencyTracker struct {
+	active, peak int32
+}
+
+func (ct *concurrencyTracker) enter() {
+	current := atomic.AddInt32(&ct.active, 1)
+	for {
+		peak := atomic.LoadInt32(&ct.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&ct.peak, peak, current) {
+			return
+		}
+	}
+}
+
+func (ct *concurrencyTracker) exit() {
+	atomic.AddInt32(&ct.active, -1)
+}
+
+func TestDispatchStrategies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		subject  eventgrid.DispatchStrategy
+		maxPeak  int32
+		inOrder  bool
+		keyOrder bool
+	}{
+		{"concurrent", eventgrid.Concurrent(), 0, false, false},
+		{"sequential", eventgrid.Sequential(), 1, true, true},
+		{"worker-pool", eventgrid.WorkerPool(3), 3, false, false},
+		{"ordered-by-subject", eventgrid.OrderedBySubject(0), 4, false, true},
+		{"ordered-by-subject-bounded", eventgrid.OrderedBySubject(2), 2, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			const count, subjects = 40, 4
+			events := newSubjectEvents(count, subjects)
+
+			var tracker concurrencyTracker
+			var lock sync.Mutex
+			var order []int
+			perSubject := make(map[string][]int)
+
+			tc.subject.Dispatch(events, func(i int, e eventgrid.Event) {
+				tracker.enter()
+				defer tracker.exit()
+
+				if events[i].ID != e.ID {
+					t.Errorf("index %d was paired with event %q", i, e.ID)
+				}
+
+				time.Sleep(time.Millisecond)
+
+				lock.Lock()
+				defer lock.Unlock()
+				order = append(order, i)
+				perSubject[e.Subject] = append(perSubject[e.Subject], i)
+			})
+
+			if got := len(order); got != count {
+				t.Fatalf("got %d events processed want: %d", got, count)
+			}
+
+			if tc.maxPeak > 0 && tracker.peak > tc.maxPeak {
+				t.Errorf("got %d events processed at once want no more than: %d", tracker.peak, tc.maxPeak)
+			}
+
+			if tc.inOrder {
+				for i, got := range order {
+					if got != i {
+						t.Errorf("position %d held event %d", i, got)
+					}
+				}
+			}
+
+			if tc.keyOrder {
+				for subject, indexes := range perSubject {
+					for j := 1; j < len(indexes); j++ {
+						if indexes[j] < indexes[j-1] {
+							t.Errorf("events for subject %q were processed out of order: %v", subject, indexes)
+							break
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_SetDispatchStrategy(t *testing.T) {
+	var tracker concurrencyTracker
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		SetDispatchStrategy(eventgrid.WorkerPool(2)).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			tracker.enter()
+			defer tracker.exit()
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.A", "Contoso.B", "Contoso.C", "Contoso.D", "Contoso.E"))
+	if got, want := receiveStatus(subject, ctx), http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if tracker.peak > 2 {
+		t.Errorf("got %d events processed at once want no more than: 2", tracker.peak)
+	}
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	if got, want := len(result), 5; got != want {
+		t.Errorf("got %d results want: %d", got, want)
+	}
+}
+
+func BenchmarkDispatchStrategies(b *testing.B) {
+	strategies := []struct {
+		name    string
+		subject eventgrid.DispatchStrategy
+	}{
+		{"concurrent", eventgrid.Concurrent()},
+		{"sequential", eventgrid.Sequential()},
+		{"worker-pool-8", eventgrid.WorkerPool(8)},
+		{"ordered-by-subject", eventgrid.OrderedBySubject(0)},
+		{"ordered-by-subject-8", eventgrid.OrderedBySubject(8)},
+	}
+
+	events := newSubjectEvents(1000, 50)
+	for _, s := range strategies {
+		b.Run(s.name, func(b *testing.B) {
+			var processed int64
+			for i := 0; i < b.N; i++ {
+				s.subject.Dispatch(events, func(int, eventgrid.Event) {
+					atomic.AddInt64(&processed, 1)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// EventMiddleware extends the definition of `buffalo.MiddlewareFunc` to `EventHandler`s.
+// It allows behavior like logging, metrics, or de-duplication to be shared by many
+// EventHandlers, instead of being repeated in each of them.
+type EventMiddleware func(EventHandler) EventHandler
+
+// wrapEventHandler applies middleware to an EventHandler. The first middleware provided
+// is the first to see each Event.
+func wrapEventHandler(h EventHandler, mw ...EventMiddleware) EventHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// recordedResult fetches the outcome reported so far for the Event being processed using c,
+// when it is an `eventgrid.Context`.
+func recordedResult(c buffalo.Context) (EventResult, bool) {
+	if ctx, ok := c.(*Context); ok && ctx.event != nil {
+		return ctx.resp.Result(ctx.index)
+	}
+	return EventResult{}, false
+}
+
+// RecoveryMiddleware is an EventMiddleware which recovers from a panicking EventHandler,
+// logs the stack trace, and reports an HTTP 500 for the Event instead.
+func RecoveryMiddleware(next EventHandler) EventHandler {
+	return func(c buffalo.Context, e Event) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = handlePanic(c, r)
+			}
+		}()
+		return next(c, e)
+	}
+}
+
+// LoggingMiddleware is an EventMiddleware which logs the type, ID, outcome, and duration
+// of processing each Event.
+func LoggingMiddleware(next EventHandler) EventHandler {
+	return func(c buffalo.Context, e Event) error {
+		start := time.Now()
+		err := next(c, e)
+
+		logger := c.Logger()
+		if logger == nil {
+			return err
+		}
+
+		logger = logger.WithFields(map[string]interface{}{
+			"event_id":   e.ID,
+			"event_type": e.EventType,
+			"duration":   time.Since(start),
+		})
+		if result, ok := recordedResult(c); ok {
+			logger = logger.WithField("status", result.Status)
+		}
+
+		if err != nil {
+			logger.Error("event failed: ", err)
+		} else {
+			logger.Info("event processed")
+		}
+		return err
+	}
+}
+
+// MaxEventSizeMiddleware is an EventMiddleware which reports an HTTP 413 for any Event that
+// is larger than `MaxEventSize` when serialized, instead of handing it to the next EventHandler.
+func MaxEventSizeMiddleware(next EventHandler) EventHandler {
+	return func(c buffalo.Context, e Event) error {
+		serialized, err := json.Marshal(e)
+		if err != nil {
+			return c.Error(http.StatusBadRequest, err)
+		}
+
+		if size := len(serialized); size > MaxEventSize {
+			return c.Error(http.StatusRequestEntityTooLarge, fmt.Errorf("event is %d bytes, which exceeds the limit of %d", size, MaxEventSize))
+		}
+		return next(c, e)
+	}
+}
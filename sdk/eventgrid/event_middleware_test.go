@@ -0,0 +1,116 @@
+package eventgrid_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func ExampleTypeDispatchSubscriber_Use() {
+	trace := func(name string) eventgrid.EventMiddleware {
+		return func(next eventgrid.EventHandler) eventgrid.EventHandler {
+			return func(c buffalo.Context, e eventgrid.Event) error {
+				fmt.Println("entering", name)
+				return next(c, e)
+			}
+		}
+	}
+
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(trace("subscriber")).
+		Bind("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event) error {
+			fmt.Println("handling", e.ID)
+			return nil
+		}, eventgrid.WithMiddleware(trace("binding")))
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{"id": "1", "eventType": "Microsoft.Storage.BlobCreated"}]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if err = subscriber.Receive(NewMockContext(req)); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// entering subscriber
+	// entering binding
+	// handling 1
+}
+
+func TestTypeDispatchSubscriber_Use_Unbound(t *testing.T) {
+	var lock sync.Mutex
+	var seen []string
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).Use(func(next eventgrid.EventHandler) eventgrid.EventHandler {
+		return func(c buffalo.Context, e eventgrid.Event) error {
+			lock.Lock()
+			seen = append(seen, e.EventType)
+			lock.Unlock()
+			return next(c, e)
+		}
+	})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Unbound"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if got := strings.Join(seen, ","); got != "Contoso.Unbound" {
+		t.Errorf("middleware saw: %q", got)
+	}
+}
+
+func TestMaxEventSizeMiddleware(t *testing.T) {
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Use(eventgrid.MaxEventSizeMiddleware).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		})
+
+	body := fmt.Sprintf(`[{"id": "small", "eventType": "Contoso.A", "data": "hi"}, {"id": "large", "eventType": "Contoso.A", "data": %q}]`, strings.Repeat("a", eventgrid.MaxEventSize))
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	ctx := NewMockContext(req)
+	receiveStatus(subject, ctx)
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	if r, _ := result.Get("small"); r.Status != http.StatusOK {
+		t.Errorf("small event got status: %d want: %d", r.Status, http.StatusOK)
+	}
+	if r, _ := result.Get("large"); r.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("large event got status: %d want: %d", r.Status, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	subject := &eventgrid.SimpleSubscriber{
+		Subscriber: eventgrid.BaseSubscriber{},
+		EventHandler: func(c buffalo.Context, e eventgrid.Event) error {
+			panic("unexpected payload")
+		},
+	}
+	subject.Use(eventgrid.RecoveryMiddleware, eventgrid.LoggingMiddleware)
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`{"id": "1", "eventType": "Contoso.A"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	err = subject.Receive(NewMockContext(req))
+	if httpErr, ok := err.(buffalo.HTTPError); !ok || httpErr.Status != http.StatusInternalServerError {
+		t.Errorf("got: %v want an HTTP 500", err)
+	}
+}
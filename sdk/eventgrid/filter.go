@@ -0,0 +1,419 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// Filter describes which Events an Event Grid Subscription should deliver. It has the same
+// shape as the "filter" property of an Event Subscription in an ARM template, so that the
+// rules enforced by Azure can also be enforced locally, either as a defense in depth or
+// while testing.
+//
+// See https://docs.microsoft.com/en-us/azure/event-grid/event-filtering for details about
+// each rule.
+type Filter struct {
+	IncludedEventTypes              []string         `json:"includedEventTypes,omitempty"`
+	SubjectBeginsWith               string           `json:"subjectBeginsWith,omitempty"`
+	SubjectEndsWith                 string           `json:"subjectEndsWith,omitempty"`
+	IsSubjectCaseSensitive          bool             `json:"isSubjectCaseSensitive,omitempty"`
+	EnableAdvancedFilteringOnArrays bool             `json:"enableAdvancedFilteringOnArrays,omitempty"`
+	AdvancedFilters                 []AdvancedFilter `json:"advancedFilters,omitempty"`
+}
+
+// AdvancedFilter compares the value found at a particular key of an Event with the values
+// it holds. The key is either the name of a property of an Event, like "subject", or a
+// dot-separated path into its data, like "data.storageDiagnostics.batchId".
+type AdvancedFilter struct {
+	OperatorType OperatorType  `json:"operatorType"`
+	Key          string        `json:"key"`
+	Value        interface{}   `json:"value,omitempty"`
+	Values       []interface{} `json:"values,omitempty"`
+}
+
+// OperatorType identifies how an AdvancedFilter compares its values with those of an Event.
+type OperatorType string
+
+// The operators which may be used by an `AdvancedFilter`.
+const (
+	OperatorNumberIn                  OperatorType = "NumberIn"
+	OperatorNumberNotIn               OperatorType = "NumberNotIn"
+	OperatorNumberLessThan            OperatorType = "NumberLessThan"
+	OperatorNumberGreaterThan         OperatorType = "NumberGreaterThan"
+	OperatorNumberLessThanOrEquals    OperatorType = "NumberLessThanOrEquals"
+	OperatorNumberGreaterThanOrEquals OperatorType = "NumberGreaterThanOrEquals"
+	OperatorNumberInRange             OperatorType = "NumberInRange"
+	OperatorNumberNotInRange          OperatorType = "NumberNotInRange"
+	OperatorBoolEquals                OperatorType = "BoolEquals"
+	OperatorStringIn                  OperatorType = "StringIn"
+	OperatorStringNotIn               OperatorType = "StringNotIn"
+	OperatorStringBeginsWith          OperatorType = "StringBeginsWith"
+	OperatorStringNotBeginsWith       OperatorType = "StringNotBeginsWith"
+	OperatorStringEndsWith            OperatorType = "StringEndsWith"
+	OperatorStringNotEndsWith         OperatorType = "StringNotEndsWith"
+	OperatorStringContains            OperatorType = "StringContains"
+	OperatorStringNotContains         OperatorType = "StringNotContains"
+	OperatorIsNullOrUndefined         OperatorType = "IsNullOrUndefined"
+	OperatorIsNotNull                 OperatorType = "IsNotNull"
+)
+
+// operator knows how to evaluate one kind of AdvancedFilter against a single, non-null value.
+// Operators which are negated succeed when the positive test fails for every value, including
+// when the key is missing altogether.
+type operator struct {
+	negated bool
+	test    func(f AdvancedFilter, value interface{}) bool
+}
+
+var operators = map[OperatorType]operator{
+	OperatorNumberIn:                  {test: numberIn},
+	OperatorNumberNotIn:               {negated: true, test: numberIn},
+	OperatorNumberLessThan:            {test: numberCompare(func(a, b float64) bool { return a < b })},
+	OperatorNumberGreaterThan:         {test: numberCompare(func(a, b float64) bool { return a > b })},
+	OperatorNumberLessThanOrEquals:    {test: numberCompare(func(a, b float64) bool { return a <= b })},
+	OperatorNumberGreaterThanOrEquals: {test: numberCompare(func(a, b float64) bool { return a >= b })},
+	OperatorNumberInRange:             {test: numberInRange},
+	OperatorNumberNotInRange:          {negated: true, test: numberInRange},
+	OperatorBoolEquals:                {test: boolEquals},
+	OperatorStringIn:                  {test: stringTest(strings.EqualFold)},
+	OperatorStringNotIn:               {negated: true, test: stringTest(strings.EqualFold)},
+	OperatorStringBeginsWith:          {test: stringTest(hasPrefixFold)},
+	OperatorStringNotBeginsWith:       {negated: true, test: stringTest(hasPrefixFold)},
+	OperatorStringEndsWith:            {test: stringTest(hasSuffixFold)},
+	OperatorStringNotEndsWith:         {negated: true, test: stringTest(hasSuffixFold)},
+	OperatorStringContains:            {test: stringTest(containsFold)},
+	OperatorStringNotContains:         {negated: true, test: stringTest(containsFold)},
+	OperatorIsNullOrUndefined:         {negated: true, test: func(AdvancedFilter, interface{}) bool { return true }},
+	OperatorIsNotNull:                 {test: func(AdvancedFilter, interface{}) bool { return true }},
+}
+
+// ParseFilter reads a Filter from JSON. Either the filter itself, or an entire Event
+// Subscription resource with the filter found at "properties.filter", may be provided.
+func ParseFilter(raw []byte) (Filter, error) {
+	var subscription struct {
+		Properties *struct {
+			Filter *Filter `json:"filter"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &subscription); err != nil {
+		return Filter{}, err
+	}
+
+	var f Filter
+	if subscription.Properties != nil && subscription.Properties.Filter != nil {
+		f = *subscription.Properties.Filter
+	} else if err := json.Unmarshal(raw, &f); err != nil {
+		return Filter{}, err
+	}
+
+	return f, f.Validate()
+}
+
+// Validate ensures that each AdvancedFilter uses a known operator, and holds values of the
+// kind that operator expects.
+func (f Filter) Validate() error {
+	for i, af := range f.AdvancedFilters {
+		if err := af.validate(); err != nil {
+			return fmt.Errorf("advanced filter %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (af AdvancedFilter) validate() error {
+	if _, ok := operators[af.OperatorType]; !ok {
+		return fmt.Errorf("unknown operator type %q", af.OperatorType)
+	}
+	if af.Key == "" {
+		return fmt.Errorf("%s requires a key", af.OperatorType)
+	}
+
+	switch af.OperatorType {
+	case OperatorNumberLessThan, OperatorNumberGreaterThan, OperatorNumberLessThanOrEquals, OperatorNumberGreaterThanOrEquals:
+		if _, ok := af.Value.(float64); !ok {
+			return fmt.Errorf("%s requires a numeric value", af.OperatorType)
+		}
+	case OperatorBoolEquals:
+		if _, ok := af.Value.(bool); !ok {
+			return fmt.Errorf("%s requires a boolean value", af.OperatorType)
+		}
+	case OperatorNumberIn, OperatorNumberNotIn:
+		for _, v := range af.Values {
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("%s requires numeric values", af.OperatorType)
+			}
+		}
+	case OperatorNumberInRange, OperatorNumberNotInRange:
+		for _, v := range af.Values {
+			if _, _, ok := asRange(v); !ok {
+				return fmt.Errorf("%s requires values which are pairs of numbers", af.OperatorType)
+			}
+		}
+	case OperatorIsNullOrUndefined, OperatorIsNotNull:
+	default:
+		for _, v := range af.Values {
+			if _, ok := v.(string); !ok {
+				return fmt.Errorf("%s requires string values", af.OperatorType)
+			}
+		}
+	}
+	return nil
+}
+
+// Matches determines whether an Event satisfies every rule of this Filter.
+func (f Filter) Matches(e Event) bool {
+	return f.Evaluate(e) == nil
+}
+
+// Evaluate checks an Event against each rule of this Filter, and describes the first one
+// that it does not satisfy. When the Event satisfies every rule, nil is returned.
+func (f Filter) Evaluate(e Event) error {
+	if len(f.IncludedEventTypes) > 0 {
+		included := false
+		for _, t := range f.IncludedEventTypes {
+			if strings.EqualFold(t, e.EventType) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return fmt.Errorf("event type %q is not one of %q", e.EventType, f.IncludedEventTypes)
+		}
+	}
+
+	hasPrefix, hasSuffix := hasPrefixFold, hasSuffixFold
+	if f.IsSubjectCaseSensitive {
+		hasPrefix, hasSuffix = strings.HasPrefix, strings.HasSuffix
+	}
+	if f.SubjectBeginsWith != "" && !hasPrefix(e.Subject, f.SubjectBeginsWith) {
+		return fmt.Errorf("subject %q does not begin with %q", e.Subject, f.SubjectBeginsWith)
+	}
+	if f.SubjectEndsWith != "" && !hasSuffix(e.Subject, f.SubjectEndsWith) {
+		return fmt.Errorf("subject %q does not end with %q", e.Subject, f.SubjectEndsWith)
+	}
+
+	if len(f.AdvancedFilters) == 0 {
+		return nil
+	}
+
+	var data interface{}
+	if len(e.Data) > 0 {
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return fmt.Errorf("unable to read event data: %v", err)
+		}
+	}
+
+	for i, af := range f.AdvancedFilters {
+		value, found := lookupFilterKey(e, data, af.Key)
+		if !af.matches(value, found, f.EnableAdvancedFilteringOnArrays) {
+			return fmt.Errorf("advanced filter %d (%s on %q) was not satisfied", i, af.OperatorType, af.Key)
+		}
+	}
+	return nil
+}
+
+// matches evaluates this AdvancedFilter against the value found at its key.
+func (af AdvancedFilter) matches(value interface{}, found bool, arrays bool) bool {
+	op, ok := operators[af.OperatorType]
+	if !ok {
+		return false
+	}
+
+	if !found || value == nil {
+		return op.negated
+	}
+
+	if elements, ok := value.([]interface{}); ok {
+		if !arrays {
+			return op.negated
+		}
+		for _, element := range elements {
+			if element != nil && op.test(af, element) {
+				return !op.negated
+			}
+		}
+		return op.negated
+	}
+
+	return op.test(af, value) != op.negated
+}
+
+// lookupFilterKey finds the value an AdvancedFilter should be evaluated against.
+func lookupFilterKey(e Event, data interface{}, key string) (interface{}, bool) {
+	switch strings.ToLower(key) {
+	case "id":
+		return e.ID, true
+	case "topic":
+		return e.Topic, true
+	case "subject":
+		return e.Subject, true
+	case "eventtype":
+		return e.EventType, true
+	case "dataversion":
+		return e.DataVersion, true
+	case "metadataversion":
+		return e.MetadataVersion, true
+	case "data":
+		return data, data != nil
+	}
+
+	segments := strings.Split(key, ".")
+	if !strings.EqualFold(segments[0], "data") {
+		return nil, false
+	}
+
+	current := data
+	for _, segment := range segments[1:] {
+		properties, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = properties[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func numberIn(f AdvancedFilter, value interface{}) bool {
+	actual, ok := value.(float64)
+	if !ok {
+		return false
+	}
+	for _, v := range f.Values {
+		if expected, ok := v.(float64); ok && expected == actual {
+			return true
+		}
+	}
+	return false
+}
+
+func numberCompare(compare func(actual, expected float64) bool) func(AdvancedFilter, interface{}) bool {
+	return func(f AdvancedFilter, value interface{}) bool {
+		actual, ok := value.(float64)
+		expected, isNumber := f.Value.(float64)
+		return ok && isNumber && compare(actual, expected)
+	}
+}
+
+func numberInRange(f AdvancedFilter, value interface{}) bool {
+	actual, ok := value.(float64)
+	if !ok {
+		return false
+	}
+	for _, v := range f.Values {
+		if low, high, ok := asRange(v); ok && low <= actual && actual <= high {
+			return true
+		}
+	}
+	return false
+}
+
+func asRange(v interface{}) (low, high float64, ok bool) {
+	pair, isPair := v.([]interface{})
+	if !isPair || len(pair) != 2 {
+		return
+	}
+	if low, ok = pair[0].(float64); !ok {
+		return
+	}
+	high, ok = pair[1].(float64)
+	return
+}
+
+func boolEquals(f AdvancedFilter, value interface{}) bool {
+	actual, ok := value.(bool)
+	expected, isBool := f.Value.(bool)
+	return ok && isBool && actual == expected
+}
+
+func stringTest(compare func(actual, expected string) bool) func(AdvancedFilter, interface{}) bool {
+	return func(f AdvancedFilter, value interface{}) bool {
+		var actual string
+		switch v := value.(type) {
+		case string:
+			actual = v
+		case float64:
+			actual = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			actual = strconv.FormatBool(v)
+		default:
+			return false
+		}
+
+		for _, v := range f.Values {
+			if expected, ok := v.(string); ok && compare(actual, expected) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func hasSuffixFold(s, suffix string) bool {
+	return len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// FilterAction decides what happens to an Event which does not match a Filter.
+type FilterAction int
+
+// The actions that can be taken with an Event which does not match a Filter.
+const (
+	// SkipUnmatched records the Event as having been processed successfully, without
+	// handing it to the EventHandler.
+	SkipUnmatched FilterAction = iota
+
+	// RejectUnmatched records an HTTP 400 for the Event, explaining which rule of the
+	// Filter was not satisfied.
+	RejectUnmatched
+)
+
+// FilterMiddleware creates an EventMiddleware which only hands Events matching a Filter to
+// the next EventHandler. The provided FilterAction decides what happens to all other Events.
+func FilterMiddleware(f Filter, action FilterAction) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(c buffalo.Context, e Event) error {
+			err := f.Evaluate(e)
+			if err == nil {
+				return next(c, e)
+			}
+
+			if action == RejectUnmatched {
+				return c.Error(http.StatusBadRequest, fmt.Errorf("event %q does not match filter: %v", e.ID, err))
+			}
+
+			if logger := c.Logger(); logger != nil {
+				logger.Debugf("skipping event %q, which does not match filter: %v", e.ID, err)
+			}
+			return nil
+		}
+	}
+}
+
+// WithFilter only hands Events matching a Filter to the EventHandler being bound. The
+// provided FilterAction decides what happens to all other Events. The Filter is applied
+// before any EventMiddleware specified using `WithMiddleware`.
+//
+// WithFilter panics if the Filter is not valid.
+func WithFilter(f Filter, action FilterAction) BindOption {
+	if err := f.Validate(); err != nil {
+		panic(err)
+	}
+
+	return func(b *binding) {
+		b.filter = FilterMiddleware(f, action)
+	}
+}
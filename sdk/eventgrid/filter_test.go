@@ -0,0 +1,172 @@
+package eventgrid_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func newFilterEvent(subject string, data string) eventgrid.Event {
+	return eventgrid.Event{
+		ID:        "1",
+		EventType: "Microsoft.Storage.BlobCreated",
+		Subject:   subject,
+		Data:      json.RawMessage(data),
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	raw, err := ioutil.ReadFile("./testdata/event_subscription.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := eventgrid.ParseFilter(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const matchingData = `{"api": "PutBlockList", "contentLength": 524288, "storageDiagnostics": {"scanned": true}, "tags": ["tabby", "cat"]}`
+	const matchingSubject = "/blobServices/default/containers/images/blobs/tabby.PNG"
+
+	testCases := []struct {
+		name  string
+		event eventgrid.Event
+		want  bool
+	}{
+		{"match", newFilterEvent(matchingSubject, matchingData), true},
+		{"event-type", eventgrid.Event{EventType: "Microsoft.Storage.BlobDeleted", Subject: matchingSubject, Data: json.RawMessage(matchingData)}, false},
+		{"subject-prefix", newFilterEvent("/blobServices/default/containers/uploads/blobs/tabby.png", matchingData), false},
+		{"subject-suffix", newFilterEvent("/blobServices/default/containers/images/blobs/tabby.jpg", matchingData), false},
+		{"number", newFilterEvent(matchingSubject, `{"api": "PutBlob", "contentLength": 10, "storageDiagnostics": {"scanned": true}, "tags": ["cat"]}`), false},
+		{"string-in-case", newFilterEvent(matchingSubject, `{"api": "putblob", "contentLength": 2048, "storageDiagnostics": {"scanned": true}, "tags": ["cat"]}`), true},
+		{"string-in", newFilterEvent(matchingSubject, `{"api": "CopyBlob", "contentLength": 2048, "storageDiagnostics": {"scanned": true}, "tags": ["cat"]}`), false},
+		{"bool", newFilterEvent(matchingSubject, `{"api": "PutBlob", "contentLength": 2048, "storageDiagnostics": {"scanned": false}, "tags": ["cat"]}`), false},
+		{"missing-key", newFilterEvent(matchingSubject, `{"api": "PutBlob", "contentLength": 2048, "tags": ["cat"]}`), false},
+		{"array", newFilterEvent(matchingSubject, `{"api": "PutBlob", "contentLength": 2048, "storageDiagnostics": {"scanned": true}, "tags": ["dog"]}`), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := subject.Evaluate(tc.event)
+			if got := err == nil; got != tc.want {
+				t.Errorf("got: %v want: %v (%v)", got, tc.want, err)
+			}
+			if got := subject.Matches(tc.event); got != tc.want {
+				t.Errorf("Matches disagrees with Evaluate")
+			}
+		})
+	}
+}
+
+func TestAdvancedFilter_Operators(t *testing.T) {
+	const data = `{"size": 5, "name": "Tabby.png", "enabled": false, "empty": null, "sizes": [1, 20]}`
+
+	testCases := []struct {
+		filter string
+		arrays bool
+		want   bool
+	}{
+		{`{"operatorType": "NumberIn", "key": "data.size", "values": [1, 5]}`, false, true},
+		{`{"operatorType": "NumberNotIn", "key": "data.size", "values": [1, 5]}`, false, false},
+		{`{"operatorType": "NumberNotIn", "key": "data.missing", "values": [1, 5]}`, false, true},
+		{`{"operatorType": "NumberLessThan", "key": "data.size", "value": 5}`, false, false},
+		{`{"operatorType": "NumberLessThanOrEquals", "key": "data.size", "value": 5}`, false, true},
+		{`{"operatorType": "NumberGreaterThanOrEquals", "key": "data.size", "value": 6}`, false, false},
+		{`{"operatorType": "NumberInRange", "key": "data.size", "values": [[0, 1], [4, 6]]}`, false, true},
+		{`{"operatorType": "NumberNotInRange", "key": "data.size", "values": [[0, 1], [4, 6]]}`, false, false},
+		{`{"operatorType": "BoolEquals", "key": "data.enabled", "value": false}`, false, true},
+		{`{"operatorType": "StringBeginsWith", "key": "data.name", "values": ["tab"]}`, false, true},
+		{`{"operatorType": "StringNotBeginsWith", "key": "data.name", "values": ["tab"]}`, false, false},
+		{`{"operatorType": "StringEndsWith", "key": "data.name", "values": [".PNG"]}`, false, true},
+		{`{"operatorType": "StringNotEndsWith", "key": "data.name", "values": [".jpg"]}`, false, true},
+		{`{"operatorType": "StringNotContains", "key": "data.name", "values": ["bb"]}`, false, false},
+		{`{"operatorType": "StringIn", "key": "subject", "values": ["/ITEMS/1"]}`, false, true},
+		{`{"operatorType": "IsNullOrUndefined", "key": "data.empty"}`, false, true},
+		{`{"operatorType": "IsNullOrUndefined", "key": "data.missing"}`, false, true},
+		{`{"operatorType": "IsNullOrUndefined", "key": "data.size"}`, false, false},
+		{`{"operatorType": "IsNotNull", "key": "data.empty"}`, false, false},
+		{`{"operatorType": "NumberGreaterThan", "key": "data.sizes", "value": 10}`, false, false},
+		{`{"operatorType": "NumberGreaterThan", "key": "data.sizes", "value": 10}`, true, true},
+		{`{"operatorType": "NumberGreaterThan", "key": "data.sizes", "value": 20}`, true, false},
+	}
+
+	event := eventgrid.Event{ID: "1", Subject: "/items/1", Data: json.RawMessage(data)}
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			var af eventgrid.AdvancedFilter
+			if err := json.Unmarshal([]byte(tc.filter), &af); err != nil {
+				t.Fatal(err)
+			}
+
+			subject := eventgrid.Filter{
+				EnableAdvancedFilteringOnArrays: tc.arrays,
+				AdvancedFilters:                 []eventgrid.AdvancedFilter{af},
+			}
+			if err := subject.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := subject.Matches(event); got != tc.want {
+				t.Errorf("got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	invalid := []string{
+		`{"advancedFilters": [{"operatorType": "NumberBetween", "key": "data.size", "value": 1}]}`,
+		`{"advancedFilters": [{"operatorType": "NumberGreaterThan", "key": "data.size", "value": "1"}]}`,
+		`{"advancedFilters": [{"operatorType": "NumberInRange", "key": "data.size", "values": [1, 2]}]}`,
+		`{"advancedFilters": [{"operatorType": "StringIn", "key": "data.name", "values": [1]}]}`,
+		`{"advancedFilters": [{"operatorType": "BoolEquals", "value": true}]}`,
+	}
+
+	for _, raw := range invalid {
+		if _, err := eventgrid.ParseFilter([]byte(raw)); err == nil {
+			t.Errorf("expected an error for %s", raw)
+		}
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	filter := eventgrid.Filter{SubjectBeginsWith: "/items/1"}
+
+	for _, tc := range []struct {
+		action  eventgrid.FilterAction
+		want    int
+		handled int
+	}{
+		{eventgrid.SkipUnmatched, http.StatusOK, 1},
+		{eventgrid.RejectUnmatched, http.StatusBadRequest, 1},
+	} {
+		handled := 0
+		subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+			SetDispatchStrategy(eventgrid.Sequential()).
+			Bind("Contoso.Items.ItemReceived", func(c buffalo.Context, e eventgrid.Event) error {
+				handled++
+				return nil
+			}, eventgrid.WithFilter(filter, tc.action))
+
+		ctx := NewMockContext(newBatchRequest(t, "Contoso.Items.ItemReceived", "Contoso.Items.ItemReceived"))
+		receiveStatus(subject, ctx)
+
+		result, _ := eventgrid.BatchResultFromContext(ctx)
+		unmatched, _ := result.Get("0")
+		if unmatched.Status != tc.want {
+			t.Errorf("got status: %d want: %d", unmatched.Status, tc.want)
+		}
+		if tc.action == eventgrid.RejectUnmatched && (unmatched.Err == nil || !strings.Contains(unmatched.Err.Error(), "does not begin with")) {
+			t.Errorf("rejection did not explain which rule failed: %v", unmatched.Err)
+		}
+		if handled != tc.handled {
+			t.Errorf("got %d events handled want: %d", handled, tc.handled)
+		}
+	}
+}
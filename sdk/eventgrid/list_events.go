@@ -0,0 +1,118 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"html/template"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// ListEvents responds with a page of the Events held by a Store, and is meant to be used by the
+// List action of a `Subscriber`. The Events are filtered using the query parameters of the
+// request, as they are read by `ParseEventQuery`. When the "Accept" header of the request
+// prefers HTML, a table of the Events is rendered, otherwise the `EventPage` is written as JSON.
+func ListEvents(c buffalo.Context, s Store) error {
+	q, err := ParseEventQuery(c.Request().URL.Query())
+	if err != nil {
+		return c.Error(http.StatusBadRequest, err)
+	}
+
+	page, err := s.Query(c, q)
+	if err != nil {
+		return c.Error(http.StatusInternalServerError, err)
+	}
+
+	rows := make([]ReceivedEvent, 0, len(page.Events))
+	for _, e := range page.Events {
+		rows = append(rows, ReceivedEvent{Event: e})
+	}
+	return renderEvents(c, q, page, rows, page.NextCursor, false)
+}
+
+// renderEvents writes a page of Events as JSON, or as HTML when the request prefers it. The
+// JSON is produced from payload, while the HTML is produced from rows, which includes the
+// outcome of processing each Event when showResults is set.
+func renderEvents(c buffalo.Context, q Query, payload interface{}, rows []ReceivedEvent, nextCursor string, showResults bool) (err error) {
+	if prefersHTML(c.Request()) {
+		next := ""
+		if nextCursor != "" {
+			q.Cursor = nextCursor
+			next = "?" + q.Values().Encode()
+		}
+
+		c.Response().Header().Set("Content-Type", "text/html; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		err = eventListTemplate.Execute(c.Response(), map[string]interface{}{
+			"query":   q,
+			"rows":    rows,
+			"results": showResults,
+			"next":    next,
+		})
+	} else {
+		c.Response().Header().Set("Content-Type", "application/json")
+		c.Response().WriteHeader(http.StatusOK)
+		err = json.NewEncoder(c.Response()).Encode(payload)
+	}
+
+	if err != nil {
+		return c.Error(http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+// prefersHTML reports whether "text/html" is listed before "application/json" in the "Accept"
+// header of a request. JSON is preferred when neither is listed.
+func prefersHTML(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			return true
+		case "application/json":
+			return false
+		}
+	}
+	return false
+}
+
+var eventListTemplate = template.Must(template.New("events").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Event Grid Events</title>
+</head>
+<body>
+	<form method="get">
+		<input name="event_type" placeholder="Event Type" value="{{.query.EventType}}">
+		<input name="subject_prefix" placeholder="Subject Prefix" value="{{.query.SubjectPrefix}}">
+		<input name="topic" placeholder="Topic" value="{{.query.Topic}}">
+		<input name="since" placeholder="Since (RFC 3339)" value="{{if not .query.Since.IsZero}}{{.query.Since.Format "2006-01-02T15:04:05Z07:00"}}{{end}}">
+		<input name="until" placeholder="Until (RFC 3339)" value="{{if not .query.Until.IsZero}}{{.query.Until.Format "2006-01-02T15:04:05Z07:00"}}{{end}}">
+		<button type="submit">Filter</button>
+	</form>
+	<table>
+		<thead>
+			<tr><th>ID</th><th>Event Type</th><th>Subject</th><th>Topic</th><th>Event Time</th>{{if .results}}<th>Status</th><th>Duration</th><th>Error</th>{{end}}</tr>
+		</thead>
+		<tbody>
+		{{- range .rows}}
+			<tr><td><a href="{{.Event.ID}}">{{.Event.ID}}</a></td><td>{{.Event.EventType}}</td><td>{{.Event.Subject}}</td><td>{{.Event.Topic}}</td><td>{{.Event.EventTime}}</td>
+			{{- if $.results}}{{with .Result}}<td>{{.Status}}</td><td>{{.Duration}}</td><td>{{if .Err}}{{.Err}}{{end}}</td>{{else}}<td colspan="3"></td>{{end}}{{end}}</tr>
+		{{- else}}
+			<tr><td colspan="{{if .results}}8{{else}}5{{end}}">No events found.</td></tr>
+		{{- end}}
+		</tbody>
+	</table>
+	{{- if .next}}
+	<a href="{{.next}}">Next page</a>
+	{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,31 @@
+package eventgrid
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gobuffalo/pop"
+)
+
+// popConnection chooses the database connection used by the pop-backed types in this package.
+// When no connection was provided explicitly, the one found in the Context under the key
+// "tx", which is where buffalo-pop's Transaction middleware places it, is used instead.
+func popConnection(ctx context.Context, conn *pop.Connection) (*pop.Connection, error) {
+	if conn != nil {
+		return conn, nil
+	}
+	if conn, ok := ctx.Value("tx").(*pop.Connection); ok {
+		return conn, nil
+	}
+	return nil, errors.New("no database connection was provided, and none was found in the Context")
+}
+
+// popTransaction runs fn in a transaction. A connection that is already a transaction belongs
+// to the request being processed, so it is used as is, and will be committed or rolled back
+// along with that request.
+func popTransaction(conn *pop.Connection, fn func(*pop.Connection) error) error {
+	if conn.TX != nil {
+		return fn(conn)
+	}
+	return conn.Transaction(fn)
+}
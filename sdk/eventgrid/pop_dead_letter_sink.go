@@ -0,0 +1,123 @@
+package eventgrid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/uuid"
+)
+
+// PopDeadLetterSinkTable is the name of the table used by a `PopDeadLetterSink`.
+const PopDeadLetterSinkTable = "eventgrid_dead_letters"
+
+// PopDeadLetterSinkMigration is a fizz migration which creates the table used by a
+// `PopDeadLetterSink`. It can be copied into an application's "migrations" folder.
+const PopDeadLetterSinkMigration = `create_table("` + PopDeadLetterSinkTable + `") {
+	t.Column("id", "uuid", {primary: true})
+	t.Column("event_id", "string", {})
+	t.Column("event_type", "string", {})
+	t.Column("topic", "text", {})
+	t.Column("subject", "text", {})
+	t.Column("event", "text", {})
+	t.Column("status", "integer", {})
+	t.Column("error", "text", {})
+	t.Column("delivery_count", "integer", {})
+	t.Column("received_at", "timestamp", {})
+}
+
+add_index("` + PopDeadLetterSinkTable + `", "event_id", {})
+`
+
+// PopDeadLetterSink is a DeadLetterSink which parks Events that could not be processed in a SQL
+// database, using pop.
+type PopDeadLetterSink struct {
+	// Connection is the database used to store DeadLetters. When it is nil, the connection
+	// found in the Context under the key "tx", which is where buffalo-pop's Transaction
+	// middleware places it, is used instead.
+	Connection *pop.Connection
+}
+
+type deadLetterRecord struct {
+	ID            uuid.UUID `db:"id"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+	EventID       string    `db:"event_id"`
+	EventType     string    `db:"event_type"`
+	Topic         string    `db:"topic"`
+	Subject       string    `db:"subject"`
+	Event         string    `db:"event"`
+	Status        int       `db:"status"`
+	Error         string    `db:"error"`
+	DeliveryCount int       `db:"delivery_count"`
+	ReceivedAt    time.Time `db:"received_at"`
+}
+
+func (deadLetterRecord) TableName() string {
+	return PopDeadLetterSinkTable
+}
+
+// Send stores a DeadLetter in the database.
+func (s PopDeadLetterSink) Send(ctx context.Context, letter DeadLetter) error {
+	conn, err := popConnection(ctx, s.Connection)
+	if err != nil {
+		return err
+	}
+
+	serialized, err := json.Marshal(letter.Event)
+	if err != nil {
+		return err
+	}
+
+	record := &deadLetterRecord{
+		EventID:       letter.Event.ID,
+		EventType:     letter.Event.EventType,
+		Topic:         letter.Event.Topic,
+		Subject:       letter.Event.Subject,
+		Event:         string(serialized),
+		Status:        letter.Result.Status,
+		DeliveryCount: letter.DeliveryCount,
+		ReceivedAt:    letter.ReceivedAt.UTC(),
+	}
+	if letter.Result.Err != nil {
+		record.Error = letter.Result.Err.Error()
+	}
+
+	return conn.Create(record)
+}
+
+// List reads every DeadLetter that has been stored in the database, oldest first.
+func (s PopDeadLetterSink) List(ctx context.Context) ([]DeadLetter, error) {
+	conn, err := popConnection(ctx, s.Connection)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []deadLetterRecord
+	if err = conn.Order("created_at asc").All(&records); err != nil {
+		return nil, err
+	}
+
+	letters := make([]DeadLetter, 0, len(records))
+	for _, record := range records {
+		letter := DeadLetter{
+			Result: EventResult{
+				ID:        record.EventID,
+				EventType: record.EventType,
+				Status:    record.Status,
+			},
+			DeliveryCount: record.DeliveryCount,
+			ReceivedAt:    record.ReceivedAt,
+		}
+		if record.Error != "" {
+			letter.Result.Err = errors.New(record.Error)
+		}
+		if err = json.Unmarshal([]byte(record.Event), &letter.Event); err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
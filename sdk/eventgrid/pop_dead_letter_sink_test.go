@@ -0,0 +1,47 @@
+//go:build sqlite
+// +build sqlite
+
+package eventgrid_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+func TestPopDeadLetterSink(t *testing.T) {
+	conn := newSQLiteConnection(t, eventgrid.PopDeadLetterSinkMigration)
+	defer conn.Close()
+
+	sink := eventgrid.PopDeadLetterSink{Connection: conn}
+	subject := newOutcomeSubscriber().
+		SetBatchPolicy(eventgrid.DeadLetterPermanentFailures).
+		SetDeadLetterSink(sink)
+
+	req := newBatchRequest(t, "Contoso.Succeed", "Contoso.Reject")
+	req.Header.Set(eventgrid.DeliveryCountHeader, "1")
+	if got, want := receiveStatus(subject, NewMockContext(req)), http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	letters, err := sink.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(letters), 1; got != want {
+		t.Fatalf("got %d dead letters want: %d", got, want)
+	}
+	letter := letters[0]
+	if letter.Event.ID != "1" || letter.Event.Subject != "/items/1" || string(letter.Event.Data) != `{"index":1}` {
+		t.Errorf("got event: %+v", letter.Event)
+	}
+	if letter.Result.Status != http.StatusBadRequest || letter.Result.Err == nil || letter.Result.Err.Error() != "rejected" {
+		t.Errorf("got result: %+v", letter.Result)
+	}
+	if letter.DeliveryCount != 1 {
+		t.Errorf("got delivery count: %d want: 1", letter.DeliveryCount)
+	}
+}
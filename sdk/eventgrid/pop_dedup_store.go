@@ -0,0 +1,127 @@
+package eventgrid
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gobuffalo/pop"
+)
+
+// PopDedupStoreTable is the name of the table used by a `PopDedupStore`.
+const PopDedupStoreTable = "eventgrid_dedup_records"
+
+// PopDedupStoreMigration is a fizz migration which creates the table used by a `PopDedupStore`.
+// It can be copied into an application's "migrations" folder.
+const PopDedupStoreMigration = `create_table("` + PopDedupStoreTable + `") {
+	t.Column("id", "string", {primary: true})
+	t.Column("expires_at", "timestamp", {})
+	t.Column("completed", "bool", {})
+	t.DisableTimestamps()
+}
+
+add_index("` + PopDedupStoreTable + `", "expires_at", {})
+`
+
+// PopDedupStore is a DedupStore which remembers processed Events in a SQL database, using
+// pop. Unlike a `MemoryDedupStore`, it may be shared by many instances of an application.
+type PopDedupStore struct {
+	// Connection is the database used to record processed Events, and is required. Unlike
+	// the other pop-backed types in this package, a PopDedupStore does not fall back to the
+	// transaction of the request being processed: that transaction is rolled back whenever
+	// a batch is answered with a failing status, which would discard the records of the
+	// Events that succeeded just as Event Grid delivers them again.
+	Connection *pop.Connection
+
+	// TTL is the amount of time each processed Event is remembered. When it is not positive,
+	// `CacheDefaultTTL` is used.
+	TTL time.Duration
+}
+
+type dedupRecord struct {
+	ID        string    `db:"id"`
+	ExpiresAt time.Time `db:"expires_at"`
+	Completed bool      `db:"completed"`
+}
+
+func (dedupRecord) TableName() string {
+	return PopDedupStoreTable
+}
+
+// connection fetches the database used by this store.
+func (s PopDedupStore) connection() (*pop.Connection, error) {
+	if s.Connection == nil {
+		return nil, errors.New("no database connection was provided to the PopDedupStore")
+	}
+	return s.Connection, nil
+}
+
+// Seen reports whether the Event identified by key was processed within the TTL of this store.
+func (s PopDedupStore) Seen(_ context.Context, key string) (bool, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return false, err
+	}
+
+	return conn.Where("id = ? AND completed = ? AND expires_at > ?", key, true, time.Now().UTC()).Exists(&dedupRecord{})
+}
+
+// Claim reserves the Event identified by key for processing by the caller, unless it was
+// already processed or is reserved by someone else. The reservation is made by inserting a
+// record, so that the primary key of the table settles concurrent claims.
+func (s PopDedupStore) Claim(_ context.Context, key string) (bool, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	err = conn.RawQuery("DELETE FROM "+PopDedupStoreTable+" WHERE id = ? AND expires_at <= ?", key, now).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	if err = conn.Create(&dedupRecord{ID: key, ExpiresAt: now.Add(DedupClaimTTL)}); err != nil {
+		// The insert fails when the Event is already claimed or complete. Any other failure
+		// leaves no record behind, and is reported.
+		if exists, existsErr := conn.Where("id = ?", key).Exists(&dedupRecord{}); existsErr == nil && exists {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Release gives up a claim on the Event identified by key. Events which were already
+// processed are left alone.
+func (s PopDedupStore) Release(_ context.Context, key string) error {
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+
+	return conn.RawQuery("DELETE FROM "+PopDedupStoreTable+" WHERE id = ? AND completed = ?", key, false).Exec()
+}
+
+// Complete records that the Event identified by key has been processed. Records which have
+// expired are removed at the same time.
+func (s PopDedupStore) Complete(_ context.Context, key string) error {
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = CacheDefaultTTL
+	}
+	now := time.Now().UTC()
+
+	return popTransaction(conn, func(tx *pop.Connection) error {
+		err := tx.RawQuery("DELETE FROM "+PopDedupStoreTable+" WHERE id = ? OR expires_at <= ?", key, now).Exec()
+		if err != nil {
+			return err
+		}
+		return tx.Create(&dedupRecord{ID: key, ExpiresAt: now.Add(ttl), Completed: true})
+	})
+}
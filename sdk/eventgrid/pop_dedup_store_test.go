@@ -0,0 +1,121 @@
+//go:build sqlite
+// +build sqlite
+
+package eventgrid_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/fizz"
+	"github.com/gobuffalo/pop"
+)
+
+func newSQLiteConnection(t *testing.T, migrations ...string) *pop.Connection {
+	conn, err := pop.NewConnection(&pop.ConnectionDetails{
+		Dialect:  "sqlite3",
+		Database: filepath.Join(t.TempDir(), "eventgrid.sqlite"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, migration := range migrations {
+		statements, err := fizz.AString(migration, conn.Dialect.FizzTranslator())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = conn.RawQuery(statements).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn
+}
+
+func TestPopDedupStore(t *testing.T) {
+	conn := newSQLiteConnection(t, eventgrid.PopDedupStoreMigration)
+	defer conn.Close()
+
+	ctx := context.Background()
+	subject := eventgrid.PopDedupStore{Connection: conn, TTL: 50 * time.Millisecond}
+
+	if seen, err := subject.Seen(ctx, "1"); err != nil || seen {
+		t.Fatalf("got seen: %v err: %v before completing", seen, err)
+	}
+	if err := subject.Complete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := subject.Complete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if seen, err := subject.Seen(ctx, "1"); err != nil || !seen {
+		t.Fatalf("got seen: %v err: %v after completing", seen, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if seen, err := subject.Seen(ctx, "1"); err != nil || seen {
+		t.Fatalf("got seen: %v err: %v after expiring", seen, err)
+	}
+}
+
+func TestPopDedupStore_Claim(t *testing.T) {
+	conn := newSQLiteConnection(t, eventgrid.PopDedupStoreMigration)
+	defer conn.Close()
+
+	ctx := context.Background()
+	subject := eventgrid.PopDedupStore{Connection: conn}
+
+	if claimed, err := subject.Claim(ctx, "1"); err != nil || !claimed {
+		t.Fatalf("got claimed: %v err: %v for an unknown key", claimed, err)
+	}
+	if claimed, err := subject.Claim(ctx, "1"); err != nil || claimed {
+		t.Errorf("got claimed: %v err: %v for a claimed key", claimed, err)
+	}
+	if seen, err := subject.Seen(ctx, "1"); err != nil || seen {
+		t.Errorf("got seen: %v err: %v for a claimed key", seen, err)
+	}
+
+	if err := subject.Release(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := subject.Claim(ctx, "1"); err != nil || !claimed {
+		t.Fatalf("got claimed: %v err: %v for a released key", claimed, err)
+	}
+
+	if err := subject.Complete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := subject.Release(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := subject.Claim(ctx, "1"); err != nil || claimed {
+		t.Errorf("got claimed: %v err: %v for a completed key", claimed, err)
+	}
+	if seen, err := subject.Seen(ctx, "1"); err != nil || !seen {
+		t.Errorf("got seen: %v err: %v for a completed key", seen, err)
+	}
+}
+
+func TestPopDedupStore_RequiresConnection(t *testing.T) {
+	conn := newSQLiteConnection(t, eventgrid.PopDedupStoreMigration)
+	defer conn.Close()
+
+	subject := eventgrid.PopDedupStore{}
+
+	ctx := context.WithValue(context.Background(), "tx", conn)
+	if _, err := subject.Seen(ctx, "1"); err == nil {
+		t.Error("expected an error when no connection was provided, rather than the request's transaction being used")
+	}
+	if _, err := subject.Claim(ctx, "1"); err == nil {
+		t.Error("expected an error when no connection was provided, rather than the request's transaction being used")
+	}
+	if err := subject.Complete(ctx, "1"); err == nil {
+		t.Error("expected an error when no connection was provided, rather than the request's transaction being used")
+	}
+}
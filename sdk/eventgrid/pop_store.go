@@ -0,0 +1,351 @@
+package eventgrid
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gobuffalo/pop"
+	"github.com/gobuffalo/pop/nulls"
+	"github.com/pkg/errors"
+)
+
+// PopStoreTable is the name of the table used by a `PopStore`.
+const PopStoreTable = "eventgrid_events"
+
+// PopStoreMigration is a fizz migration which creates the table used by a `PopStore`. It is
+// written to an application's "migrations" folder by `buffalo generate eventgrid` when the
+// "--migrations" flag is provided, or it can be copied there by hand.
+const PopStoreMigration = `create_table("` + PopStoreTable + `") {
+	t.Column("id", "integer", {primary: true})
+	t.Column("event_id", "string", {})
+	t.Column("event_type", "string", {})
+	t.Column("topic", "text", {})
+	t.Column("subject", "text", {})
+	t.Column("event_time", "timestamp", {null: true})
+	t.Column("event", "text", {})
+	t.Column("result", "text", {null: true})
+	t.Column("expires_at", "timestamp", {})
+	t.DisableTimestamps()
+}
+
+add_index("` + PopStoreTable + `", "event_id", {})
+add_index("` + PopStoreTable + `", "expires_at", {})
+add_index("` + PopStoreTable + `", "event_time", {})
+`
+
+// PopStoreDownMigration is a fizz migration which removes the table created by
+// `PopStoreMigration`.
+const PopStoreDownMigration = `drop_table("` + PopStoreTable + `")
+`
+
+// PopStore is a Store which holds Events in a SQL database, using pop. Unlike a `MemoryStore`,
+// the Events it holds survive restarts of an application, and are seen by every instance of it.
+type PopStore struct {
+	// Connection is the database used to hold Events, and is required. Like a
+	// `PopDedupStore`, a PopStore does not fall back to the transaction of the request being
+	// processed: that transaction is rolled back whenever a batch is answered with a failing
+	// status, which would discard the record of a failed batch just when it is needed most.
+	Connection *pop.Connection
+
+	// TTL is the amount of time each Event is held. When it is not positive, `CacheDefaultTTL`
+	// is used.
+	TTL time.Duration
+
+	// MaxDepth is the largest number of Events that are held. When it is zero,
+	// `CacheDefaultMaxDepth` is used.
+	MaxDepth uint
+}
+
+type storeRecord struct {
+	ID        int          `db:"id"`
+	EventID   string       `db:"event_id"`
+	EventType string       `db:"event_type"`
+	Topic     string       `db:"topic"`
+	Subject   string       `db:"subject"`
+	EventTime nulls.Time   `db:"event_time"`
+	Event     string       `db:"event"`
+	Result    nulls.String `db:"result"`
+	ExpiresAt time.Time    `db:"expires_at"`
+}
+
+func (storeRecord) TableName() string {
+	return PopStoreTable
+}
+
+func (r storeRecord) event() (e Event, err error) {
+	err = json.Unmarshal([]byte(r.Event), &e)
+	return
+}
+
+func (r storeRecord) result() (*EventResult, error) {
+	if !r.Result.Valid {
+		return nil, nil
+	}
+
+	var result EventResult
+	if err := json.Unmarshal([]byte(r.Result.String), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r storeRecord) received() (ReceivedEvent, error) {
+	e, err := r.event()
+	if err != nil {
+		return ReceivedEvent{}, err
+	}
+	result, err := r.result()
+	return ReceivedEvent{Event: e, Result: result}, err
+}
+
+// connection fetches the database used by this store.
+func (s PopStore) connection() (*pop.Connection, error) {
+	if s.Connection == nil {
+		return nil, errors.New("no database connection was provided to the PopStore")
+	}
+	return s.Connection, nil
+}
+
+func (s PopStore) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return CacheDefaultTTL
+	}
+	return s.TTL
+}
+
+func (s PopStore) maxDepth() uint {
+	if s.MaxDepth == 0 {
+		return CacheDefaultMaxDepth
+	}
+	return s.MaxDepth
+}
+
+// Add records an Event. Events which have expired, or which no longer fit within the
+// MaxDepth of this store, are removed at the same time. Both are found using an index,
+// rather than by reading through the Events which are held.
+func (s PopStore) Add(ctx context.Context, e Event) error {
+	return s.add(ctx, e, nil)
+}
+
+// Record records an Event along with the outcome of processing it, in the same way as Add.
+func (s PopStore) Record(ctx context.Context, e Event, result EventResult) error {
+	return s.add(ctx, e, &result)
+}
+
+func (s PopStore) add(ctx context.Context, e Event, result *EventResult) error {
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+
+	serialized, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	var serializedResult nulls.String
+	if result != nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		serializedResult = nulls.NewString(string(raw))
+	}
+	now := time.Now().UTC()
+
+	var eventTime nulls.Time
+	if parsed, ok := parseEventTime(e); ok {
+		eventTime = nulls.NewTime(parsed.UTC())
+	}
+
+	return popTransaction(conn, func(tx *pop.Connection) error {
+		err := tx.RawQuery("DELETE FROM "+PopStoreTable+" WHERE expires_at <= ?", now).Exec()
+		if err != nil {
+			return err
+		}
+
+		created := &storeRecord{
+			EventID:   e.ID,
+			EventType: e.EventType,
+			Topic:     e.Topic,
+			Subject:   e.Subject,
+			EventTime: eventTime,
+			Event:     string(serialized),
+			Result:    serializedResult,
+			ExpiresAt: now.Add(s.ttl()),
+		}
+		if err = tx.Create(created); err != nil {
+			return err
+		}
+
+		// IDs are assigned in increasing order, so the records which no longer fit have an
+		// ID at least MaxDepth below the one just created. Gaps in the IDs, such as those
+		// left by a rolled back transaction, can only leave fewer records behind.
+		oldest := created.ID - int(s.maxDepth())
+		if oldest <= 0 {
+			return nil
+		}
+		return tx.RawQuery("DELETE FROM "+PopStoreTable+" WHERE id <= ?", oldest).Exec()
+	})
+}
+
+// List reads all of the unexpired Events, starting with the most recently arriving.
+func (s PopStore) List(ctx context.Context) ([]Event, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []storeRecord
+	err = conn.Where("expires_at > ?", time.Now().UTC()).Order("id DESC").Limit(int(s.maxDepth())).All(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Event, 0, len(records))
+	for _, record := range records {
+		e, err := record.event()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, e)
+	}
+	return results, nil
+}
+
+// Query reads a page of the unexpired Events which match a `Query`, starting with the most
+// recently arriving.
+func (s PopStore) Query(ctx context.Context, q Query) (EventPage, error) {
+	page, _, err := s.query(ctx, q)
+	return page, err
+}
+
+// QueryReceived reads a page of the unexpired Events which match a `Query`, starting with the
+// most recently arriving, along with the outcome that was recorded for each of them.
+func (s PopStore) QueryReceived(ctx context.Context, q Query) (ReceivedEventPage, error) {
+	page, results, err := s.query(ctx, q)
+	if err != nil {
+		return ReceivedEventPage{}, err
+	}
+	return receivedPage(page, results), nil
+}
+
+func (s PopStore) query(ctx context.Context, q Query) (EventPage, []*EventResult, error) {
+	if err := q.validate(); err != nil {
+		return EventPage{}, nil, err
+	}
+	start, _ := q.position()
+
+	conn, err := s.connection()
+	if err != nil {
+		return EventPage{}, nil, err
+	}
+
+	query := conn.Where("expires_at > ?", time.Now().UTC())
+	if start != 0 {
+		query = query.Where("id < ?", start)
+	}
+	if q.EventType != "" {
+		query = query.Where("LOWER(event_type) = ?", strings.ToLower(q.EventType))
+	}
+	if q.Topic != "" {
+		query = query.Where("LOWER(topic) = ?", strings.ToLower(q.Topic))
+	}
+	if q.SubjectPrefix != "" {
+		query = query.Where(subjectPrefixClause(conn), utf8.RuneCountInString(q.SubjectPrefix), q.SubjectPrefix)
+	}
+	if !q.Since.IsZero() {
+		query = query.Where("event_time >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		query = query.Where("event_time < ?", q.Until.UTC())
+	}
+
+	// One more record than fits on the page shows whether there is a next page.
+	var records []storeRecord
+	if err = query.Order("id DESC").Limit(q.limit() + 1).All(&records); err != nil {
+		return EventPage{}, nil, err
+	}
+
+	page := newPager(q)
+	var results []*EventResult
+	for _, record := range records {
+		received, err := record.received()
+		if err != nil {
+			return EventPage{}, nil, err
+		}
+		if !page.add(received.Event, uint64(record.ID)) {
+			break
+		}
+		results = append(results, received.Result)
+	}
+	return page.page, results, nil
+}
+
+// subjectPrefixClause matches the subjects which begin with a prefix, given its length and
+// the prefix itself. Like `Cache`, it is case-sensitive: LIKE is not, under the default
+// collations of MySQL and SQLite, and neither is = under MySQL's, so bytes are compared there.
+func subjectPrefixClause(conn *pop.Connection) string {
+	if conn.Dialect.Name() == "mysql" {
+		return "BINARY SUBSTR(subject, 1, ?) = ?"
+	}
+	return "SUBSTR(subject, 1, ?) = ?"
+}
+
+// Get fetches the most recently arriving, unexpired Event with a particular ID.
+func (s PopStore) Get(ctx context.Context, id string) (Event, bool, error) {
+	found, ok, err := s.GetReceived(ctx, id)
+	return found.Event, ok, err
+}
+
+// GetReceived fetches the most recently arriving, unexpired Event with a particular ID, along
+// with the outcome that was recorded for it.
+func (s PopStore) GetReceived(ctx context.Context, id string) (ReceivedEvent, bool, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return ReceivedEvent{}, false, err
+	}
+
+	var record storeRecord
+	err = conn.Where("event_id = ? AND expires_at > ?", id, time.Now().UTC()).Order("id DESC").First(&record)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return ReceivedEvent{}, false, nil
+	} else if err != nil {
+		return ReceivedEvent{}, false, err
+	}
+
+	found, err := record.received()
+	return found, err == nil, err
+}
+
+// Delete removes every Event with a particular ID, and reports whether there were any.
+func (s PopStore) Delete(ctx context.Context, id string) (bool, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return false, err
+	}
+
+	var found bool
+	err = popTransaction(conn, func(tx *pop.Connection) error {
+		found, err = tx.Where("event_id = ?", id).Exists(&storeRecord{})
+		if err != nil || !found {
+			return err
+		}
+		return tx.RawQuery("DELETE FROM "+PopStoreTable+" WHERE event_id = ?", id).Exec()
+	})
+	return found, err
+}
+
+// Clear removes every Event.
+func (s PopStore) Clear(ctx context.Context) error {
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+
+	return conn.RawQuery("DELETE FROM " + PopStoreTable).Exec()
+}
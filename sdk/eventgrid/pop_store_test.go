@@ -0,0 +1,52 @@
+//go:build sqlite
+// +build sqlite
+
+package eventgrid_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+func TestPopStore(t *testing.T) {
+	testStore(t, func(t *testing.T, ttl time.Duration, maxDepth uint) eventgrid.Store {
+		conn := newSQLiteConnection(t, eventgrid.PopStoreMigration)
+		t.Cleanup(func() {
+			conn.Close()
+		})
+
+		return eventgrid.PopStore{Connection: conn, TTL: ttl, MaxDepth: maxDepth}
+	})
+}
+
+func TestPopStore_RequiresConnection(t *testing.T) {
+	conn := newSQLiteConnection(t, eventgrid.PopStoreMigration)
+	defer conn.Close()
+
+	subject := eventgrid.PopStore{}
+
+	ctx := context.WithValue(context.Background(), "tx", conn)
+	if err := subject.Add(ctx, eventgrid.Event{ID: "1"}); err == nil {
+		t.Error("expected an error when no connection was provided, rather than the request's transaction being used")
+	}
+	if _, err := subject.Query(ctx, eventgrid.Query{}); err == nil {
+		t.Error("expected an error when no connection was provided, rather than the request's transaction being used")
+	}
+}
+
+func TestPopStoreDownMigration(t *testing.T) {
+	conn := newSQLiteConnection(t, eventgrid.PopStoreMigration, eventgrid.PopStoreDownMigration)
+	defer conn.Close()
+
+	var count int
+	err := conn.RawQuery("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", eventgrid.PopStoreTable).First(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("table %q still exists after the down migration", eventgrid.PopStoreTable)
+	}
+}
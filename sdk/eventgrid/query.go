@@ -0,0 +1,201 @@
+package eventgrid
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryDefaultLimit is the number of Events returned in a page when a `Query` does not
+// specify a Limit.
+const QueryDefaultLimit = 50
+
+// QueryMaxLimit is the largest number of Events that will be returned in a single page.
+const QueryMaxLimit = 1000
+
+// Query describes which Events held by a `Cache` or a `Store` should be read, and how many of
+// them at a time. Each filter which is left empty is not applied. Matching Events are returned
+// in pages, starting with the most recently arriving.
+type Query struct {
+	// EventType only matches Events of this type. Like the rest of Event Grid, it is not
+	// case-sensitive.
+	EventType string
+
+	// SubjectPrefix only matches Events with a subject starting with this string.
+	SubjectPrefix string
+
+	// Topic only matches Events published by this topic. It is not case-sensitive.
+	Topic string
+
+	// Since only matches Events whose "eventTime" is at, or after, this time.
+	Since time.Time
+
+	// Until only matches Events whose "eventTime" is before this time.
+	Until time.Time
+
+	// Limit is the largest number of Events to return in a single page. When it is zero,
+	// `QueryDefaultLimit` is used. It may not be larger than `QueryMaxLimit`.
+	Limit int
+
+	// Cursor identifies where the page being requested starts. It should either be empty, to
+	// request the first page, or be the NextCursor of the previous page.
+	Cursor string
+}
+
+// EventPage is a group of Events that match a `Query`.
+type EventPage struct {
+	Events []Event `json:"events"`
+
+	// NextCursor should be used as the Cursor of a `Query` in order to fetch the next page.
+	// It is empty when there are no more matching Events.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ParseEventQuery reads a Query from the parameters of a URL. The parameters "event_type",
+// "subject_prefix", "topic", "limit" and "cursor" correspond to the fields of a Query.
+// "since" and "until" should be formatted as described by RFC 3339.
+func ParseEventQuery(values url.Values) (q Query, err error) {
+	q = Query{
+		EventType:     values.Get("event_type"),
+		SubjectPrefix: values.Get("subject_prefix"),
+		Topic:         values.Get("topic"),
+		Cursor:        values.Get("cursor"),
+	}
+
+	if raw := values.Get("since"); raw != "" {
+		if q.Since, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return Query{}, fmt.Errorf("unable to parse \"since\": %v", err)
+		}
+	}
+
+	if raw := values.Get("until"); raw != "" {
+		if q.Until, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return Query{}, fmt.Errorf("unable to parse \"until\": %v", err)
+		}
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		if q.Limit, err = strconv.Atoi(raw); err != nil {
+			return Query{}, fmt.Errorf("unable to parse \"limit\": %v", err)
+		}
+	}
+
+	return q, q.validate()
+}
+
+// Values writes a Query as the parameters of a URL, as they are read by `ParseEventQuery`.
+func (q Query) Values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	set("event_type", q.EventType)
+	set("subject_prefix", q.SubjectPrefix)
+	set("topic", q.Topic)
+	if !q.Since.IsZero() {
+		set("since", q.Since.Format(time.RFC3339Nano))
+	}
+	if !q.Until.IsZero() {
+		set("until", q.Until.Format(time.RFC3339Nano))
+	}
+	if q.Limit != 0 {
+		set("limit", strconv.Itoa(q.Limit))
+	}
+	set("cursor", q.Cursor)
+	return values
+}
+
+func (q Query) validate() error {
+	if q.Limit < 0 || q.Limit > QueryMaxLimit {
+		return fmt.Errorf("limit must not be negative or larger than %d, got: %d", QueryMaxLimit, q.Limit)
+	}
+	_, err := q.position()
+	return err
+}
+
+func (q Query) limit() int {
+	if q.Limit == 0 {
+		return QueryDefaultLimit
+	}
+	return q.Limit
+}
+
+// position reads the Cursor of a Query. Each Store gives the Events it holds a number which
+// increases in the order they arrived, and a cursor is the number of the last Event on the
+// previous page. Zero means that there was no previous page.
+func (q Query) position() (uint64, error) {
+	if q.Cursor == "" {
+		return 0, nil
+	}
+
+	position, err := strconv.ParseUint(q.Cursor, 36, 64)
+	if err != nil || position == 0 {
+		return 0, fmt.Errorf("invalid cursor %q", q.Cursor)
+	}
+	return position, nil
+}
+
+func queryCursor(position uint64) string {
+	return strconv.FormatUint(position, 36)
+}
+
+// matches reports whether an Event satisfies every filter of a Query. Events whose time can
+// not be read never match a Query with a time window.
+func (q Query) matches(e Event) bool {
+	if q.EventType != "" && !strings.EqualFold(q.EventType, e.EventType) {
+		return false
+	}
+
+	if q.Topic != "" && !strings.EqualFold(q.Topic, e.Topic) {
+		return false
+	}
+
+	if !strings.HasPrefix(e.Subject, q.SubjectPrefix) {
+		return false
+	}
+
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+
+	eventTime, ok := parseEventTime(e)
+	if !ok {
+		return false
+	}
+	return !eventTime.Before(q.Since) && (q.Until.IsZero() || eventTime.Before(q.Until))
+}
+
+func parseEventTime(e Event) (time.Time, bool) {
+	parsed, err := time.Parse(time.RFC3339Nano, e.EventTime)
+	return parsed, err == nil
+}
+
+// pager collects the Events on a page, as matching Events are visited starting with the most
+// recently arriving.
+type pager struct {
+	page  EventPage
+	limit int
+	last  uint64
+}
+
+func newPager(q Query) *pager {
+	return &pager{limit: q.limit(), page: EventPage{Events: []Event{}}}
+}
+
+// add includes an Event on the page, and reports whether more are wanted. Once the page is
+// full, one more matching Event shows that there is a next page.
+func (p *pager) add(e Event, position uint64) bool {
+	if len(p.page.Events) == p.limit {
+		p.page.NextCursor = queryCursor(p.last)
+		return false
+	}
+
+	p.page.Events = append(p.page.Events, e)
+	p.last = position
+	return true
+}
@@ -0,0 +1,171 @@
+package eventgrid_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+func ExampleCache_Query() {
+	var subject eventgrid.Cache
+
+	subject.Add(eventgrid.Event{ID: "1", EventType: "Contoso.Order.Created", Subject: "/orders/1"})
+	subject.Add(eventgrid.Event{ID: "2", EventType: "Contoso.Order.Shipped", Subject: "/orders/1"})
+	subject.Add(eventgrid.Event{ID: "3", EventType: "Contoso.Order.Created", Subject: "/orders/2"})
+	subject.Add(eventgrid.Event{ID: "4", EventType: "Contoso.Order.Created", Subject: "/orders/3"})
+
+	q := eventgrid.Query{EventType: "Contoso.Order.Created", Limit: 2}
+	for {
+		page, err := subject.Query(q)
+		if err != nil {
+			return
+		}
+
+		for _, e := range page.Events {
+			fmt.Println(e.ID, e.Subject)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	// Output:
+	// 4 /orders/3
+	// 3 /orders/2
+	// 1 /orders/1
+}
+
+func TestParseEventQuery(t *testing.T) {
+	want := eventgrid.Query{
+		EventType:     "Contoso.Order.Created",
+		SubjectPrefix: "/orders/",
+		Topic:         "/topics/orders",
+		Since:         time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC),
+		Until:         time.Date(2018, 6, 2, 10, 0, 0, 500, time.UTC),
+		Limit:         20,
+		Cursor:        "2s",
+	}
+
+	got, err := eventgrid.ParseEventQuery(want.Values())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v\nwant: %+v", got, want)
+	}
+
+	empty, err := eventgrid.ParseEventQuery(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty != (eventgrid.Query{}) {
+		t.Errorf("got: %+v want an empty Query", empty)
+	}
+	if encoded := empty.Values().Encode(); encoded != "" {
+		t.Errorf("got: %q want no parameters for an empty Query", encoded)
+	}
+
+	for _, raw := range []string{"since=yesterday", "until=2018-06-01", "limit=many", "limit=-1", "limit=1001", "cursor=!"} {
+		values, err := url.ParseQuery(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = eventgrid.ParseEventQuery(values); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	store := eventgrid.NewMemoryStore(time.Hour, 10)
+	for _, e := range []eventgrid.Event{
+		{ID: "1", EventType: "Contoso.Order.Created", Subject: "/orders/1"},
+		{ID: "2", EventType: "Contoso.Order.Shipped", Subject: "/orders/1"},
+		{ID: "3", EventType: "Contoso.Order.Created", Subject: "/orders/<2>"},
+	} {
+		if err := store.Add(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(t *testing.T, target, accept string) *MockContext {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		ctx := NewMockContext(req)
+		if err := eventgrid.ListEvents(ctx, store); err != nil {
+			t.Fatal(err)
+		}
+		return ctx
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		for _, accept := range []string{"", "application/json", "application/json, text/html", "text/html;q=0, */*"} {
+			ctx := list(t, "/?event_type=contoso.order.created&limit=1", accept)
+
+			if got := ctx.Status(); got != http.StatusOK {
+				t.Errorf("got status: %d want: %d", got, http.StatusOK)
+			}
+			if got := ctx.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("got content type: %q for Accept: %q", got, accept)
+			}
+
+			var page eventgrid.EventPage
+			if err := json.NewDecoder(ctx.Body()).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Events) != 1 || page.Events[0].ID != "3" || page.NextCursor == "" {
+				t.Errorf("got: %+v for Accept: %q", page, accept)
+			}
+		}
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		ctx := list(t, "/?event_type=contoso.order.created&limit=1", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+		if got := ctx.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+			t.Errorf("got content type: %q", got)
+		}
+
+		body, err := ioutil.ReadAll(ctx.Body())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{
+			`<a href="3">3</a>`,
+			`/orders/&lt;2&gt;`,
+			`value="contoso.order.created"`,
+			`href="?cursor=`,
+		} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("expected the page to contain %q, got:\n%s", want, body)
+			}
+		}
+		if strings.Contains(string(body), "Contoso.Order.Shipped") {
+			t.Error("got an Event which does not match the query")
+		}
+	})
+
+	t.Run("Bad query", func(t *testing.T) {
+		ctx := NewMockContext(httptest.NewRequest(http.MethodGet, "/?limit=many", nil))
+		if err := eventgrid.ListEvents(ctx, store); err == nil {
+			t.Error("expected an error for an invalid query")
+		}
+	})
+}
@@ -21,13 +21,18 @@ type App buffalo.App
 // Subscriber creates a group of mappings (*buffalo.App) between
 // a Subscriber interface implementation and the appropriate REST
 // paths.
-func (a *App) Subscriber(p string, s Subscriber) *buffalo.App {
+func (a *App) Subscriber(p string, s Subscriber, opts ...SubscriberOption) *buffalo.App {
+	cfg := newSubscriberConfig(opts)
 	g := (*buffalo.App)(a).Group(p)
 	p = "/"
 
-	g.POST(p, s.Receive)
+	receive := cfg.authenticate(s.Receive)
+	g.POST(p, receive)
+	g.OPTIONS(p, cfg.webHook.Handler)
 	if a.Env == "development" {
-		g.GET(path.Join(p, "new"), s.New)
+		console := consoleHandler(s, receive)
+		g.GET(path.Join(p, "new"), console)
+		g.POST(path.Join(p, "new"), console)
 	}
 
 	return g
@@ -0,0 +1,192 @@
+package eventgrid
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Schema is a JSON Schema which the data carried by Events can be checked against, before they
+// are handed to an EventHandler.
+//
+// External documentation on JSON Schema can be found here:
+// https://json-schema.org
+type Schema struct {
+	raw    []byte
+	schema *gojsonschema.Schema
+}
+
+// ParseSchema reads a JSON Schema, for example one embedded in an application.
+func ParseSchema(raw []byte) (*Schema, error) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse JSON schema: %v", err)
+	}
+	return &Schema{raw: raw, schema: schema}, nil
+}
+
+// MustParseSchema reads a JSON Schema in the same way as `ParseSchema`, but panics if it is
+// invalid. It is meant for schemas which are embedded in an application.
+func MustParseSchema(raw string) *Schema {
+	schema, err := ParseSchema([]byte(raw))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// LoadSchemaFile reads a JSON Schema from a file.
+func LoadSchemaFile(path string) (*Schema, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSchema(raw)
+}
+
+// String fetches the JSON Schema as it was read.
+func (s *Schema) String() string {
+	return string(s.raw)
+}
+
+// SchemaViolation describes one way in which the data carried by an Event fails to satisfy
+// a Schema.
+type SchemaViolation struct {
+	// Pointer is a JSON Pointer, as described by RFC 6901, to the offending value within the
+	// data. It is empty when the data as a whole is at fault.
+	Pointer string `json:"pointer"`
+
+	// Message describes the requirement that was not satisfied.
+	Message string `json:"message"`
+}
+
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// SchemaValidationError is reported when the data carried by an Event fails to satisfy the
+// Schema bound along with its EventHandler.
+type SchemaValidationError struct {
+	EventID    string
+	EventType  string
+	Violations []SchemaViolation
+}
+
+func (err SchemaValidationError) Error() string {
+	violations := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		violations = append(violations, violation.String())
+	}
+	return fmt.Sprintf("data of event %q (%s) does not satisfy its schema: %s", err.EventID, err.EventType, strings.Join(violations, "; "))
+}
+
+// Permanent indicates that delivering an Event again will not help it satisfy its Schema.
+func (SchemaValidationError) Permanent() bool {
+	return true
+}
+
+// Validate checks the data carried by an Event against this Schema. Should it not be
+// satisfied, a `SchemaValidationError` describing each violation is returned.
+func (s *Schema) Validate(e Event) error {
+	data := []byte(e.Data)
+	if len(data) == 0 {
+		data = []byte("null")
+	}
+
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return SchemaValidationError{
+			EventID:    e.ID,
+			EventType:  e.EventType,
+			Violations: []SchemaViolation{{Message: err.Error()}},
+		}
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	violations := make([]SchemaViolation, 0, len(result.Errors()))
+	for _, failure := range result.Errors() {
+		violations = append(violations, SchemaViolation{
+			Pointer: violationPointer(failure),
+			Message: failure.Description(),
+		})
+	}
+
+	return SchemaValidationError{
+		EventID:    e.ID,
+		EventType:  e.EventType,
+		Violations: violations,
+	}
+}
+
+// violationPointer converts the location of a failure reported by gojsonschema into a JSON
+// Pointer. When a required property is missing, the pointer identifies that property.
+func violationPointer(failure gojsonschema.ResultError) string {
+	const separator = "\x00"
+
+	var segments []string
+	if ctx := failure.Context(); ctx != nil {
+		segments = strings.Split(ctx.String(separator), separator)[1:]
+	}
+
+	if failure.Type() == "required" {
+		if property, ok := failure.Details()["property"].(string); ok {
+			segments = append(segments, property)
+		}
+	}
+
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var pointer strings.Builder
+	for _, segment := range segments {
+		pointer.WriteString("/")
+		pointer.WriteString(escaper.Replace(segment))
+	}
+	return pointer.String()
+}
+
+// SchemaMiddleware creates an EventMiddleware which checks the data carried by each Event
+// against a Schema before handing it to the next EventHandler. Events which do not satisfy
+// the Schema are not handed on. Instead, each violation is logged along with the JSON Pointer
+// to the offending value, and an HTTP 400 is recorded with a `SchemaValidationError`.
+func SchemaMiddleware(s *Schema) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(c buffalo.Context, e Event) error {
+			err := s.Validate(e)
+			if err == nil {
+				return next(c, e)
+			}
+
+			if validationErr, ok := err.(SchemaValidationError); ok {
+				if logger := c.Logger(); logger != nil {
+					for _, violation := range validationErr.Violations {
+						logger.Warnf("event %q violates its schema at %q: %s", e.ID, violation.Pointer, violation.Message)
+					}
+				}
+			}
+			return c.Error(http.StatusBadRequest, err)
+		}
+	}
+}
+
+// WithSchema checks the data carried by each Event against a Schema before it is handed to
+// the EventHandler being bound, as described by `SchemaMiddleware`. Events that are skipped
+// or rejected using `WithFilter` are not checked.
+func WithSchema(s *Schema) BindOption {
+	if s == nil {
+		panic("eventgrid: schema must not be nil")
+	}
+
+	return func(b *binding) {
+		b.schema = s
+	}
+}
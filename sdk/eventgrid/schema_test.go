@@ -0,0 +1,158 @@
+package eventgrid_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func ExampleWithSchema() {
+	schema := eventgrid.MustParseSchema(`{
+		"type": "object",
+		"required": ["url"],
+		"properties": {"url": {"type": "string"}}
+	}`)
+
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event) error {
+			fmt.Println("handling", e.ID)
+			return nil
+		}, eventgrid.WithSchema(schema))
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{"id": "1", "eventType": "Microsoft.Storage.BlobCreated", "data": {"url": 42}}]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	ctx := NewMockContext(req)
+	subscriber.Receive(ctx)
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	failure, _ := result.Get("1")
+	fmt.Println(failure.Status)
+	for _, violation := range failure.Err.(eventgrid.SchemaValidationError).Violations {
+		fmt.Println(violation.Pointer)
+	}
+
+	// Output:
+	// 400
+	// /url
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := eventgrid.LoadSchemaFile(filepath.Join("testdata", "order.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"valid", `{"id": "1", "customer": {"email": "ada@contoso.com"}, "lines": [{"sku/variant": "a", "quantity": 2}]}`, nil},
+		{"missing property", `{"id": "1", "customer": {"email": "ada@contoso.com"}}`, []string{"/lines"}},
+		{"missing nested property", `{"id": "1", "customer": {}, "lines": [{}]}`, []string{"/customer/email"}},
+		{"array item", `{"id": "1", "customer": {"email": "ada@contoso.com"}, "lines": [{"quantity": 1}, {"quantity": 0}]}`, []string{"/lines/1/quantity"}},
+		{"escaped property", `{"id": "1", "customer": {"email": "ada@contoso.com"}, "lines": [{"sku/variant": 3}]}`, []string{"/lines/0/sku~1variant"}},
+		{"wrong type", `[]`, []string{""}},
+		{"missing data", ``, []string{""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.Validate(eventgrid.Event{ID: "1", EventType: "Contoso.Order.Created", Data: json.RawMessage(tc.data)})
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			validationErr, ok := err.(eventgrid.SchemaValidationError)
+			if !ok {
+				t.Fatalf("got error %T want: eventgrid.SchemaValidationError", err)
+			}
+
+			var got []string
+			for _, violation := range validationErr.Violations {
+				got = append(got, violation.Pointer)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("got pointers: %q want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	if _, err := eventgrid.ParseSchema([]byte(`{"type": 42}`)); err == nil {
+		t.Error("expected an error")
+	}
+
+	if _, err := eventgrid.LoadSchemaFile(filepath.Join("testdata", "missing.schema.json")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_Schema(t *testing.T) {
+	schema := eventgrid.MustParseSchema(`{
+		"type": "object",
+		"properties": {"index": {"type": "integer", "maximum": 1}}
+	}`)
+
+	handled := make(chan string, 3)
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Contoso.Validated", func(c buffalo.Context, e eventgrid.Event) error {
+			handled <- e.ID
+			return nil
+		}, eventgrid.WithSchema(schema))
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Validated", "Contoso.Validated", "Contoso.Validated"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	close(handled)
+
+	var ids []string
+	for id := range handled {
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 {
+		t.Errorf("got handled events: %v want: 2 of them", ids)
+	}
+
+	result, ok := eventgrid.BatchResultFromContext(ctx)
+	if !ok {
+		t.Fatal("no BatchResult was associated with the Context")
+	}
+
+	failure, ok := result.Get("2")
+	if !ok {
+		t.Fatal("no result for event \"2\"")
+	}
+
+	if failure.Status != http.StatusBadRequest {
+		t.Errorf("got status: %d want: %d", failure.Status, http.StatusBadRequest)
+	}
+
+	if !eventgrid.DefaultErrorClassifier.Permanent(failure) {
+		t.Error("schema violations should be permanent")
+	}
+
+	serialized, err := json.Marshal(failure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(serialized), "/index") {
+		t.Errorf("failure record %s does not mention the offending JSON pointer", serialized)
+	}
+}
@@ -10,10 +10,17 @@ import (
 type SimpleSubscriber struct {
 	Subscriber
 	EventHandler
+	middleware []EventMiddleware
+}
+
+// Use adds EventMiddleware which will be run before the EventHandler sees each Event.
+func (s *SimpleSubscriber) Use(mw ...EventMiddleware) *SimpleSubscriber {
+	s.middleware = append(s.middleware, mw...)
+	return s
 }
 
 // Receive unmarshals the body of the request as an Event Grid Event, and hands it to the
-// EventHandler for further processing.
+// EventHandler for further processing. The Event can be read using `BatchEventsFromContext`.
 func (s SimpleSubscriber) Receive(c buffalo.Context) (err error) {
 	var event Event
 
@@ -21,6 +28,7 @@ func (s SimpleSubscriber) Receive(c buffalo.Context) (err error) {
 	if err != nil {
 		return
 	}
+	c.Set(batchEventsKey, []Event{event})
 
-	return s.EventHandler(c, event)
+	return wrapEventHandler(s.EventHandler, s.middleware...)(c, event)
 }
@@ -0,0 +1,122 @@
+package eventgrid
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds recently received Events for a limited amount of time, so that they may be
+// inspected later, along with the outcome of processing them when it was recorded.
+// Implementations should keep each Event for a TTL, and no more than a maximum number of
+// Events, removing the least recently arriving ones first.
+type Store interface {
+	// Add records an Event.
+	Add(ctx context.Context, e Event) error
+
+	// Record records an Event along with the outcome of processing it.
+	Record(ctx context.Context, e Event, result EventResult) error
+
+	// List reads all of the unexpired Events, starting with the most recently arriving.
+	List(ctx context.Context) ([]Event, error)
+
+	// Query reads a page of the unexpired Events which match a `Query`, starting with the
+	// most recently arriving.
+	Query(ctx context.Context, q Query) (EventPage, error)
+
+	// QueryReceived reads a page of the unexpired Events which match a `Query` in the same
+	// way as Query, along with the outcome that was recorded for each of them.
+	QueryReceived(ctx context.Context, q Query) (ReceivedEventPage, error)
+
+	// Get fetches the most recently arriving, unexpired Event with a particular ID.
+	Get(ctx context.Context, id string) (Event, bool, error)
+
+	// GetReceived fetches the most recently arriving, unexpired Event with a particular ID,
+	// along with the outcome that was recorded for it.
+	GetReceived(ctx context.Context, id string) (ReceivedEvent, bool, error)
+
+	// Delete removes every Event with a particular ID, and reports whether there were any.
+	Delete(ctx context.Context, id string) (bool, error)
+
+	// Clear removes every Event.
+	Clear(ctx context.Context) error
+}
+
+// MemoryStore is a Store which holds Events in memory, using a `Cache`. It is used when no
+// other Store is provided, and is suitable for applications which are run as a single
+// instance. The zero value holds up to `CacheDefaultMaxDepth` Events for `CacheDefaultTTL`.
+type MemoryStore struct {
+	cache Cache
+}
+
+// NewMemoryStore creates a MemoryStore which holds each Event for the provided length of
+// time, and no more than `maxDepth` Events.
+func NewMemoryStore(ttl time.Duration, maxDepth uint) *MemoryStore {
+	created := &MemoryStore{}
+	created.cache.SetTTL(ttl)
+	created.cache.SetMaxDepth(maxDepth)
+	return created
+}
+
+// Add records an Event.
+func (s *MemoryStore) Add(_ context.Context, e Event) error {
+	s.cache.Add(e)
+	return nil
+}
+
+// Record records an Event along with the outcome of processing it.
+func (s *MemoryStore) Record(_ context.Context, e Event, result EventResult) error {
+	s.cache.Record(e, result)
+	return nil
+}
+
+// List reads all of the unexpired Events, starting with the most recently arriving.
+func (s *MemoryStore) List(_ context.Context) ([]Event, error) {
+	return s.cache.List(), nil
+}
+
+// Query reads a page of the unexpired Events which match a `Query`, starting with the most
+// recently arriving.
+func (s *MemoryStore) Query(_ context.Context, q Query) (EventPage, error) {
+	return s.cache.Query(q)
+}
+
+// QueryReceived reads a page of the unexpired Events which match a `Query`, starting with the
+// most recently arriving, along with the outcome that was recorded for each of them.
+func (s *MemoryStore) QueryReceived(_ context.Context, q Query) (ReceivedEventPage, error) {
+	return s.cache.QueryReceived(q)
+}
+
+// Get fetches the most recently arriving, unexpired Event with a particular ID.
+func (s *MemoryStore) Get(_ context.Context, id string) (Event, bool, error) {
+	found, ok := s.cache.Get(id)
+	return found, ok, nil
+}
+
+// GetReceived fetches the most recently arriving, unexpired Event with a particular ID, along
+// with the outcome that was recorded for it.
+func (s *MemoryStore) GetReceived(_ context.Context, id string) (ReceivedEvent, bool, error) {
+	found, ok := s.cache.GetReceived(id)
+	return found, ok, nil
+}
+
+// Delete removes every Event with a particular ID, and reports whether there were any.
+func (s *MemoryStore) Delete(_ context.Context, id string) (bool, error) {
+	return s.cache.Delete(id), nil
+}
+
+// Clear removes every Event.
+func (s *MemoryStore) Clear(_ context.Context) error {
+	s.cache.Clear()
+	return nil
+}
+
+// StartJanitor starts a goroutine which removes expired Events every `interval`, until the
+// provided Context is done or `Close` is called. See `Cache.StartJanitor`.
+func (s *MemoryStore) StartJanitor(ctx context.Context, interval time.Duration) {
+	s.cache.StartJanitor(ctx, interval)
+}
+
+// Close stops the janitor of this store, if one is running.
+func (s *MemoryStore) Close() error {
+	return s.cache.Close()
+}
@@ -0,0 +1,358 @@
+package eventgrid_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+// testStore checks that a Store behaves as described by the Store interface. It is shared by
+// every Store in this package. newStore should create an empty Store which holds each Event
+// for ttl, and no more than maxDepth of them.
+func testStore(t *testing.T, newStore func(t *testing.T, ttl time.Duration, maxDepth uint) eventgrid.Store) {
+	ctx := context.Background()
+
+	add := func(t *testing.T, subject eventgrid.Store, events ...eventgrid.Event) {
+		t.Helper()
+		for _, e := range events {
+			if err := subject.Add(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	listIDs := func(t *testing.T, subject eventgrid.Store) string {
+		t.Helper()
+		events, err := subject.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]string, 0, len(events))
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	t.Run("List", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		if got := listIDs(t, subject); got != "" {
+			t.Errorf("got: %q want an empty store", got)
+		}
+
+		add(t, subject,
+			eventgrid.Event{ID: "a", EventType: "Contoso.A", Subject: "/items/1", Data: []byte(`{"index":1}`)},
+			eventgrid.Event{ID: "b"},
+			eventgrid.Event{ID: "c"})
+
+		if got, want := listIDs(t, subject), "c,b,a"; got != want {
+			t.Errorf("got: %q want: %q", got, want)
+		}
+
+		events, err := subject.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := events[2]; got.EventType != "Contoso.A" || got.Subject != "/items/1" || string(got.Data) != `{"index":1}` {
+			t.Errorf("event was not faithfully stored, got: %+v", got)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		if _, ok, err := subject.Get(ctx, "a"); err != nil || ok {
+			t.Errorf("got found: %v err: %v in an empty store", ok, err)
+		}
+
+		add(t, subject,
+			eventgrid.Event{ID: "a", Subject: "first"},
+			eventgrid.Event{ID: "b"},
+			eventgrid.Event{ID: "a", Subject: "second"})
+
+		found, ok, err := subject.Get(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || found.Subject != "second" {
+			t.Errorf("got: %q, %v want the most recently arriving event", found.Subject, ok)
+		}
+
+		if _, ok, err = subject.Get(ctx, "missing"); err != nil || ok {
+			t.Errorf("got found: %v err: %v for a missing event", ok, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		add(t, subject, eventgrid.Event{ID: "a"}, eventgrid.Event{ID: "b"}, eventgrid.Event{ID: "a"})
+
+		if deleted, err := subject.Delete(ctx, "a"); err != nil || !deleted {
+			t.Errorf("got deleted: %v err: %v", deleted, err)
+		}
+		if deleted, err := subject.Delete(ctx, "a"); err != nil || deleted {
+			t.Errorf("got deleted: %v err: %v for events which were already deleted", deleted, err)
+		}
+
+		if got, want := listIDs(t, subject), "b"; got != want {
+			t.Errorf("got: %q want: %q", got, want)
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		add(t, subject, eventgrid.Event{ID: "a"}, eventgrid.Event{ID: "b"})
+		if err := subject.Clear(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := listIDs(t, subject); got != "" {
+			t.Errorf("got: %q want an empty store", got)
+		}
+
+		add(t, subject, eventgrid.Event{ID: "c"})
+		if got, want := listIDs(t, subject), "c"; got != want {
+			t.Errorf("got: %q want: %q", got, want)
+		}
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 2)
+
+		add(t, subject, eventgrid.Event{ID: "a"}, eventgrid.Event{ID: "b"}, eventgrid.Event{ID: "c"})
+
+		if got, want := listIDs(t, subject), "c,b"; got != want {
+			t.Errorf("got: %q want: %q", got, want)
+		}
+		if _, ok, err := subject.Get(ctx, "a"); err != nil || ok {
+			t.Errorf("got found: %v err: %v for an evicted event", ok, err)
+		}
+
+		if _, err := subject.Delete(ctx, "b"); err != nil {
+			t.Fatal(err)
+		}
+		add(t, subject, eventgrid.Event{ID: "d"})
+
+		if got, want := listIDs(t, subject), "d,c"; got != want {
+			t.Errorf("got: %q want: %q", got, want)
+		}
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		add(t, subject,
+			eventgrid.Event{ID: "1", EventType: "Contoso.Order.Created", Topic: "/topics/orders", Subject: "/orders/1", EventTime: "2018-06-01T10:00:00Z"},
+			eventgrid.Event{ID: "2", EventType: "Contoso.Order.Shipped", Topic: "/topics/orders", Subject: "/orders/1", EventTime: "2018-06-01T11:00:00Z"},
+			eventgrid.Event{ID: "3", EventType: "Contoso.Order.Created", Topic: "/topics/orders", Subject: "/orders/2", EventTime: "2018-06-02T10:00:00Z"},
+			eventgrid.Event{ID: "4", EventType: "Contoso.Invoice.Created", Topic: "/topics/invoices", Subject: "/invoices/%_1"},
+			eventgrid.Event{ID: "5", EventType: "Contoso.Order.Created", Topic: "/topics/orders", Subject: "/orders_archive/3", EventTime: "2018-06-03T10:00:00+02:00"})
+
+		testCases := []struct {
+			name  string
+			query eventgrid.Query
+			want  string
+		}{
+			{"everything", eventgrid.Query{}, "5,4,3,2,1"},
+			{"event type", eventgrid.Query{EventType: "contoso.order.created"}, "5,3,1"},
+			{"topic", eventgrid.Query{Topic: "/TOPICS/INVOICES"}, "4"},
+			{"subject prefix", eventgrid.Query{SubjectPrefix: "/orders/"}, "3,2,1"},
+			{"subject prefix with wildcards", eventgrid.Query{SubjectPrefix: "/invoices/%_"}, "4"},
+			{"subject prefix of another case", eventgrid.Query{SubjectPrefix: "/Orders/"}, ""},
+			{"since", eventgrid.Query{Since: time.Date(2018, 6, 1, 11, 0, 0, 0, time.UTC)}, "5,3,2"},
+			{"until", eventgrid.Query{Until: time.Date(2018, 6, 3, 8, 0, 0, 0, time.UTC)}, "3,2,1"},
+			{"combined", eventgrid.Query{EventType: "Contoso.Order.Created", SubjectPrefix: "/orders/", Since: time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC)}, "3"},
+			{"nothing", eventgrid.Query{EventType: "Contoso.Missing"}, ""},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				page, err := subject.Query(ctx, tc.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				ids := make([]string, 0, len(page.Events))
+				for _, e := range page.Events {
+					ids = append(ids, e.ID)
+				}
+				if got := strings.Join(ids, ","); got != tc.want {
+					t.Errorf("got: %q want: %q", got, tc.want)
+				}
+				if page.NextCursor != "" {
+					t.Errorf("got next cursor: %q want none", page.NextCursor)
+				}
+			})
+		}
+	})
+
+	t.Run("Query pages", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		for _, id := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+			eventType := "Contoso.Even"
+			if id[0]%2 == 1 {
+				eventType = "Contoso.Odd"
+			}
+			add(t, subject, eventgrid.Event{ID: id, EventType: eventType})
+		}
+
+		q := eventgrid.Query{EventType: "Contoso.Odd", Limit: 2}
+		var pages []string
+		for i := 0; i < 5; i++ {
+			page, err := subject.Query(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := make([]string, 0, len(page.Events))
+			for _, e := range page.Events {
+				ids = append(ids, e.ID)
+			}
+			pages = append(pages, strings.Join(ids, ","))
+
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+
+			// Events arriving between pages do not affect the pages which follow.
+			add(t, subject, eventgrid.Event{ID: "late", EventType: "Contoso.Odd"})
+		}
+
+		if got, want := strings.Join(pages, "|"), "7,5|3,1"; got != want {
+			t.Errorf("got pages: %q want: %q", got, want)
+		}
+
+		if _, err := subject.Query(ctx, eventgrid.Query{Cursor: "not a cursor"}); err == nil {
+			t.Error("expected an error for an invalid cursor")
+		}
+		if _, err := subject.Query(ctx, eventgrid.Query{Limit: eventgrid.QueryMaxLimit + 1}); err == nil {
+			t.Error("expected an error for a limit which is too large")
+		}
+	})
+
+	t.Run("Record", func(t *testing.T) {
+		subject := newStore(t, time.Hour, 10)
+
+		add(t, subject, eventgrid.Event{ID: "a", EventType: "Contoso.A"})
+		err := subject.Record(ctx, eventgrid.Event{ID: "b", EventType: "Contoso.B"}, eventgrid.EventResult{
+			ID:        "b",
+			EventType: "Contoso.B",
+			Status:    http.StatusBadRequest,
+			Err:       errors.New("rejected"),
+			Duration:  time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, ok, err := subject.GetReceived(ctx, "b")
+		if err != nil || !ok {
+			t.Fatalf("got found: %v err: %v", ok, err)
+		}
+		if found.Event.EventType != "Contoso.B" || found.Result == nil {
+			t.Fatalf("got: %+v want the event along with its outcome", found)
+		}
+		if r := found.Result; r.Status != http.StatusBadRequest || r.Err == nil || r.Err.Error() != "rejected" || r.Duration != time.Second {
+			t.Errorf("got result: %+v", r)
+		}
+
+		if found, ok, err = subject.GetReceived(ctx, "a"); err != nil || !ok || found.Result != nil {
+			t.Errorf("got: %+v found: %v err: %v want an event without an outcome", found, ok, err)
+		}
+
+		page, err := subject.QueryReceived(ctx, eventgrid.Query{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Events) != 2 || page.Events[0].Event.ID != "b" || page.Events[0].Result == nil || page.Events[1].Result != nil {
+			t.Errorf("got: %+v", page)
+		}
+
+		page, err = subject.QueryReceived(ctx, eventgrid.Query{EventType: "contoso.a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Events) != 1 || page.Events[0].Event.ID != "a" {
+			t.Errorf("got: %+v", page)
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		const ttl = 100 * time.Millisecond
+		subject := newStore(t, ttl, 10)
+
+		add(t, subject, eventgrid.Event{ID: "a"})
+		if _, ok, err := subject.Get(ctx, "a"); err != nil || !ok {
+			t.Fatalf("got found: %v err: %v before the event expired", ok, err)
+		}
+
+		time.Sleep(2 * ttl)
+		add(t, subject, eventgrid.Event{ID: "b"})
+
+		if got, want := listIDs(t, subject), "b"; got != want {
+			t.Errorf("got: %q want: %q", got, want)
+		}
+		if _, ok, err := subject.Get(ctx, "a"); err != nil || ok {
+			t.Errorf("got found: %v err: %v for an expired event", ok, err)
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T, ttl time.Duration, maxDepth uint) eventgrid.Store {
+		return eventgrid.NewMemoryStore(ttl, maxDepth)
+	})
+}
+
+func TestBoltStore(t *testing.T) {
+	testStore(t, func(t *testing.T, ttl time.Duration, maxDepth uint) eventgrid.Store {
+		subject, err := eventgrid.OpenBoltStore(filepath.Join(t.TempDir(), "events.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			subject.Close()
+		})
+
+		subject.TTL, subject.MaxDepth = ttl, maxDepth
+		return subject
+	})
+}
+
+func TestBoltStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	subject, err := eventgrid.OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = subject.Add(ctx, eventgrid.Event{ID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = subject.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err = eventgrid.OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subject.Close()
+
+	if _, ok, err := subject.Get(ctx, "a"); err != nil || !ok {
+		t.Errorf("got found: %v err: %v after reopening the store", ok, err)
+	}
+}
@@ -0,0 +1,146 @@
+package eventgrid
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// subjectPattern matches the Subject of an Event, which is a path like
+// "/blobServices/default/containers/images/blobs/cats/tabby.png". Each segment of the pattern
+// is either matched literally, or captured as a parameter. A parameter is written as
+// "{name}", and captures a single segment. A final parameter written as "{name*}" captures
+// every remaining segment, including the slashes between them.
+type subjectPattern struct {
+	raw      string
+	segments []subjectSegment
+	literals int
+	catchAll bool
+}
+
+type subjectSegment struct {
+	value   string
+	isParam bool
+}
+
+// parseSubjectPattern validates and compiles a pattern for matching Event Subjects.
+func parseSubjectPattern(raw string) (*subjectPattern, error) {
+	parsed := &subjectPattern{raw: raw}
+
+	parts := strings.Split(raw, "/")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") && !strings.HasSuffix(part, "}") {
+			if strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("segment %q of subject pattern %q must either be a parameter or contain no braces", part, raw)
+			}
+			parsed.segments = append(parsed.segments, subjectSegment{value: part})
+			parsed.literals++
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+		if len(name) != len(part)-2 || name == "" || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("segment %q of subject pattern %q is not a valid parameter", part, raw)
+		}
+
+		if strings.HasSuffix(name, "*") {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("parameter %q of subject pattern %q must be its final segment", part, raw)
+			}
+			name = strings.TrimSuffix(name, "*")
+			parsed.catchAll = true
+		}
+		if name == "" {
+			return nil, fmt.Errorf("segment %q of subject pattern %q is not a valid parameter", part, raw)
+		}
+		parsed.segments = append(parsed.segments, subjectSegment{value: name, isParam: true})
+	}
+
+	return parsed, nil
+}
+
+// match determines whether a Subject fits this pattern, and if so, which values were
+// captured for each of its parameters.
+func (p *subjectPattern) match(subject string) (map[string]string, bool) {
+	parts := strings.Split(subject, "/")
+	if len(parts) < len(p.segments) || (!p.catchAll && len(parts) != len(p.segments)) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range p.segments {
+		switch {
+		case !segment.isParam:
+			if parts[i] != segment.value {
+				return nil, false
+			}
+		case p.catchAll && i == len(p.segments)-1:
+			rest := strings.Join(parts[i:], "/")
+			if rest == "" {
+				return nil, false
+			}
+			params[segment.value] = rest
+		default:
+			if parts[i] == "" {
+				return nil, false
+			}
+			params[segment.value] = parts[i]
+		}
+	}
+	return params, true
+}
+
+// precedes determines whether pattern p should be tried before pattern other. Patterns with
+// more literal segments are more specific, so they are preferred, as are patterns which do
+// not end by capturing every remaining segment.
+func (p *subjectPattern) precedes(other *subjectPattern) bool {
+	if p.literals != other.literals {
+		return p.literals > other.literals
+	}
+	if p.catchAll != other.catchAll {
+		return !p.catchAll
+	}
+	if len(p.segments) != len(other.segments) {
+		return len(p.segments) > len(other.segments)
+	}
+	return p.raw < other.raw
+}
+
+// WithSubject restricts the EventHandler being bound to Events with a Subject matching the
+// provided pattern, for example "/blobServices/default/containers/{container}/blobs/{blob*}".
+// Values captured by the pattern's parameters can be read from the `buffalo.Context` handed
+// to the EventHandler using `Param`, just like the parameters of a Buffalo route.
+//
+// Many EventHandlers may be bound to the same Event Type, so long as each has a different
+// Subject pattern. The pattern with the most literal segments is tried first. Events with
+// Subjects that match none of them fall through to the next matching Event Type binding.
+//
+// WithSubject panics if the pattern is not valid.
+func WithSubject(pattern string) BindOption {
+	parsed, err := parseSubjectPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(b *binding) {
+		b.subject = parsed
+	}
+}
+
+// subjectParamValues offers the parameters captured from an Event's Subject, falling back to
+// those of the request which delivered it.
+type subjectParamValues struct {
+	captured map[string]string
+	parent   buffalo.ParamValues
+}
+
+func (v subjectParamValues) Get(key string) string {
+	if value, ok := v.captured[key]; ok {
+		return value
+	}
+	if v.parent == nil {
+		return ""
+	}
+	return v.parent.Get(key)
+}
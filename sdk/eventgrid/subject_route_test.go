@@ -0,0 +1,118 @@
+package eventgrid_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func ExampleWithSubject() {
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event) error {
+			fmt.Printf("%s was added to %s\n", c.Param("blob"), c.Param("container"))
+			return nil
+		}, eventgrid.WithSubject("/blobServices/default/containers/{container}/blobs/{blob*}"))
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{
+	"id": "831e1650-001e-001b-66ab-eeb76e069631",
+	"eventType": "Microsoft.Storage.BlobCreated",
+	"subject": "/blobServices/default/containers/images/blobs/cats/tabby.png"
+}]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if err = subscriber.Receive(NewMockContext(req)); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output: cats/tabby.png was added to images
+}
+
+func TestWithSubject_Routing(t *testing.T) {
+	const eventType = "Microsoft.Storage.BlobCreated"
+
+	var got string
+	record := func(name string) eventgrid.EventHandler {
+		return func(c buffalo.Context, e eventgrid.Event) error {
+			got = fmt.Sprintf("%s container=%q blob=%q", name, c.Param("container"), c.Param("blob"))
+			return nil
+		}
+	}
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		SetDispatchStrategy(eventgrid.Sequential()).
+		Bind(eventType, record("any-blob"), eventgrid.WithSubject("/blobServices/default/containers/{container}/blobs/{blob*}")).
+		Bind(eventType, record("thumbnail"), eventgrid.WithSubject("/blobServices/default/containers/{container}/blobs/thumbnails/{blob}")).
+		Bind(eventType, record("uploads"), eventgrid.WithSubject("/blobServices/default/containers/uploads/blobs/{blob*}")).
+		Bind(eventType, record("type-only")).
+		Bind("Microsoft.Storage.*", record("pattern"), eventgrid.WithSubject("/queueServices/default/queues/{container}"))
+
+	testCases := []struct {
+		eventType string
+		subject   string
+		want      string
+	}{
+		{eventType, "/blobServices/default/containers/images/blobs/cats/tabby.png", `any-blob container="images" blob="cats/tabby.png"`},
+		{eventType, "/blobServices/default/containers/images/blobs/thumbnails/tabby.png", `thumbnail container="images" blob="tabby.png"`},
+		{eventType, "/blobServices/default/containers/images/blobs/thumbnails/cats/tabby.png", `any-blob container="images" blob="thumbnails/cats/tabby.png"`},
+		{eventType, "/blobServices/default/containers/uploads/blobs/thumbnails/tabby.png", `thumbnail container="uploads" blob="tabby.png"`},
+		{eventType, "/blobServices/default/containers/uploads/blobs/cats/tabby.png", `uploads container="" blob="cats/tabby.png"`},
+		{eventType, "/blobServices/default/containers/images/blobs/", `type-only container="" blob=""`},
+		{eventType, "/queueServices/default/queues/orders", `type-only container="" blob=""`},
+		{"Microsoft.Storage.BlobDeleted", "/queueServices/default/queues/orders", `pattern container="orders" blob=""`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.subject, func(t *testing.T) {
+			got = ""
+			body := fmt.Sprintf(`[{"id": "1", "eventType": %q, "subject": %q}]`, tc.eventType, tc.subject)
+			req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", "application/json")
+
+			if status := receiveStatus(subject, NewMockContext(req)); status != http.StatusOK {
+				t.Errorf("got status: %d want: %d", status, http.StatusOK)
+			}
+			if got != tc.want {
+				t.Errorf("\ngot:  %s\nwant: %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, ok := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind(eventType, record("any-blob"), eventgrid.WithSubject("/blobServices/{blob*}")).
+		Handler(eventType); ok {
+		t.Error("Handler should not consider bindings restricted to a Subject")
+	}
+}
+
+func TestWithSubject_Invalid(t *testing.T) {
+	patterns := []string{
+		"/containers/{container",
+		"/containers/container}",
+		"/containers/{}",
+		"/containers/{*}",
+		"/containers/{blob*}/metadata",
+		"/containers/prefix-{container}",
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			eventgrid.WithSubject(pattern)
+		})
+	}
+}
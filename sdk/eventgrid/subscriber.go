@@ -29,6 +29,10 @@ type Event struct {
 	EventTime       string          `json:"eventTime"`
 	MetadataVersion string          `json:"metadataVersion"`
 	DataVersion     string          `json:"dataVersion"`
+
+	// DataSchema identifies the schema that Data adheres to. It is only populated for Events
+	// that were delivered using the CloudEvents schema, from their "dataschema" attribute.
+	DataSchema string `json:"dataSchema,omitempty"`
 }
 
 // UnmarshalData attempts to read the value associated with the "data" property
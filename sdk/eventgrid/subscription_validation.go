@@ -1,10 +1,15 @@
 package eventgrid
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/uuid"
@@ -14,23 +19,108 @@ import (
 // event sent by an Event Grid Topic.
 type SubscriptionValidationRequest struct {
 	ValidationCode uuid.UUID `json:"validationCode,omitempty"`
+	ValidationURL  string    `json:"validationUrl,omitempty"`
+}
+
+// AsyncValidationDefaultMaxAttempts is the number of times a validation URL will be
+// visited before giving up, when no other value has been specified.
+const AsyncValidationDefaultMaxAttempts = 5
+
+// AsyncValidationDefaultRetryDelay is the amount of time waited before the first retry
+// of a failed visit to a validation URL, when no other value has been specified.
+// The delay doubles after each subsequent failure.
+const AsyncValidationDefaultRetryDelay = 2 * time.Second
+
+// AsyncValidation configures how a subscription is validated by visiting the
+// `validationUrl` sent by Event Grid, instead of echoing the `validationCode` in
+// the response body.
+//
+// External documentation on manual validation can be found here:
+// https://docs.microsoft.com/en-us/azure/event-grid/webhook-event-delivery#endpoint-validation-with-event-grid-events
+type AsyncValidation struct {
+	// Approve is consulted before the validation URL is visited. Returning an error
+	// denies the subscription. When nil, `ApproveEventGridValidationURL` is used, so that
+	// only URLs belonging to Event Grid are visited.
+	Approve func(Event, SubscriptionValidationRequest) error
+
+	// OnResult is called once validation has either succeeded, been denied, or
+	// exhausted its attempts. A nil error indicates the subscription was validated.
+	OnResult func(SubscriptionValidationRequest, error)
+
+	// Client is used to visit the validation URL. When nil, `http.DefaultClient` is used.
+	Client *http.Client
+
+	// MaxAttempts is the number of times the validation URL will be visited before
+	// giving up. When zero or less, `AsyncValidationDefaultMaxAttempts` is used.
+	MaxAttempts int
+
+	// RetryDelay is the amount of time waited before the first retry. When zero or
+	// less, `AsyncValidationDefaultRetryDelay` is used.
+	RetryDelay time.Duration
+}
+
+// ValidationURLHostSuffix is the domain that the validation URLs sent by Event Grid belong to.
+const ValidationURLHostSuffix = ".eventgrid.azure.net"
+
+// ApproveEventGridValidationURL is the default `AsyncValidation.Approve` hook. It only approves
+// validation URLs which use "https" and belong to `ValidationURLHostSuffix`, so that a request
+// for validation can not be used to make this server visit an arbitrary URL.
+func ApproveEventGridValidationURL(_ Event, svr SubscriptionValidationRequest) error {
+	parsed, err := url.Parse(svr.ValidationURL)
+	if err != nil {
+		return err
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if parsed.Scheme != "https" || parsed.User != nil || !strings.HasSuffix(host, ValidationURLHostSuffix) {
+		return fmt.Errorf("validation URL %q does not belong to Event Grid", svr.ValidationURL)
+	}
+	return nil
+}
+
+// ValidationOption customizes the behavior of `SubscriptionValidationMiddleware`.
+type ValidationOption func(*validationConfig)
+
+type validationConfig struct {
+	async *AsyncValidation
+}
+
+// WithAsyncValidation opts in to completing the subscription validation handshake by
+// visiting the `validationUrl` in the background. An HTTP 200 is returned immediately.
+// Requests that do not carry a `validationUrl` are still answered by echoing their
+// `validationCode`.
+func WithAsyncValidation(v AsyncValidation) ValidationOption {
+	return func(cfg *validationConfig) {
+		cfg.async = &v
+	}
 }
 
 // SubscriptionValidationMiddleware provides a `buffalo.Handler` which will triage all incoming requests
 // to either submit it for event processing, or echo back the response the server expects to validate a
 // subscription.
-func SubscriptionValidationMiddleware(next buffalo.Handler) buffalo.Handler {
+func SubscriptionValidationMiddleware(next buffalo.Handler, opts ...ValidationOption) buffalo.Handler {
+	cfg := &validationConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	return func(c buffalo.Context) error {
 		if typeHeader := c.Request().Header.Get("Aeg-Event-Type"); strings.EqualFold(typeHeader, "SubscriptionValidation") {
-			var events []Event
-			if err := c.Bind(&events); err != nil {
-				return c.Error(http.StatusBadRequest, err)
+			events, oversized, err := readEventBatch(NewEventDecoder(c.Request().Body).Decode)
+			if err != nil {
+				return c.Error(readStatus(err), err)
+			}
+			if err, ok := oversized[0]; ok {
+				return c.Error(http.StatusRequestEntityTooLarge, err)
 			}
 
 			if numEvents := len(events); numEvents != 1 {
 				return c.Error(http.StatusBadRequest, fmt.Errorf("expected exactly 1 event, got %d", numEvents))
 			}
 
+			if cfg.async != nil {
+				return cfg.async.receive(c, events[0])
+			}
 			return ReceiveSubscriptionValidationRequest(c, events[0])
 		}
 		return next(c)
@@ -64,3 +154,96 @@ func ReceiveSubscriptionValidationRequest(c buffalo.Context, e Event) error {
 	c.Response().WriteHeader(http.StatusOK)
 	return nil
 }
+
+func (v AsyncValidation) receive(c buffalo.Context, e Event) error {
+	var svr SubscriptionValidationRequest
+	if err := json.Unmarshal(e.Data, &svr); err != nil {
+		return c.Error(http.StatusBadRequest, err)
+	}
+
+	if svr.ValidationURL == "" {
+		return ReceiveSubscriptionValidationRequest(c, e)
+	}
+
+	logger := c.Logger()
+	if logger != nil {
+		logger.Info("received asynchronous validation request from: ", c.Request().RemoteAddr)
+	}
+
+	go func() {
+		err := v.validate(e, svr)
+		if logger != nil {
+			if err == nil {
+				logger.Info("subscription validated for topic: ", e.Topic)
+			} else {
+				logger.Error("subscription not validated for topic: ", e.Topic, " ", err)
+			}
+		}
+		if v.OnResult != nil {
+			v.OnResult(svr, err)
+		}
+	}()
+
+	c.Response().WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (v AsyncValidation) validate(e Event, svr SubscriptionValidationRequest) error {
+	approve := v.Approve
+	if approve == nil {
+		approve = ApproveEventGridValidationURL
+	}
+	if err := approve(e, svr); err != nil {
+		return fmt.Errorf("subscription denied: %v", err)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	attempts := v.MaxAttempts
+	if attempts <= 0 {
+		attempts = AsyncValidationDefaultMaxAttempts
+	}
+
+	delay := v.RetryDelay
+	if delay <= 0 {
+		delay = AsyncValidationDefaultRetryDelay
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			<-time.After(delay)
+			delay *= 2
+		}
+
+		if err = visitValidationURL(client, svr.ValidationURL); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %v", attempts, err)
+}
+
+func visitValidationURL(client *http.Client, url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("validation URL responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Azure/buffalo-azure/sdk/eventgrid"
 	"github.com/gobuffalo/buffalo"
@@ -123,3 +128,119 @@ func TestSubscriptionValidationMiddleware_OtherEvent(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSubscriptionValidationMiddleware_AsyncValidation(t *testing.T) {
+	var visits int32
+	validator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&visits, 1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer validator.Close()
+
+	testCases := []struct {
+		name    string
+		approve func(eventgrid.Event, eventgrid.SubscriptionValidationRequest) error
+		wantErr bool
+	}{
+		{"approved", func(eventgrid.Event, eventgrid.SubscriptionValidationRequest) error {
+			return nil
+		}, false},
+		{"denied", func(eventgrid.Event, eventgrid.SubscriptionValidationRequest) error {
+			return errors.New("unknown topic")
+		}, true},
+		{"foreign URL", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&visits, 0)
+			results := make(chan error, 1)
+
+			subject := eventgrid.SubscriptionValidationMiddleware(func(c buffalo.Context) error {
+				t.Error("`SubscriptionValidationMiddleware` did not detect request for validation")
+				return nil
+			}, eventgrid.WithAsyncValidation(eventgrid.AsyncValidation{
+				Approve:    tc.approve,
+				RetryDelay: time.Millisecond,
+				OnResult: func(_ eventgrid.SubscriptionValidationRequest, err error) {
+					results <- err
+				},
+			}))
+
+			req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(fmt.Sprintf(`[{
+	"id": "2d1781af-3a4c-4d7c-bd0c-e34b19da4e66",
+	"topic": "/subscriptions/xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx",
+	"subject": "",
+	"data": {
+		"validationCode": "512d38b6-c7b8-40c8-89fe-f46f9e9622b6",
+		"validationUrl": %q
+	},
+	"eventType": "Microsoft.EventGrid.SubscriptionValidationEvent",
+	"eventTime": "2018-01-25T22:12:19.4556811Z",
+	"metadataVersion": "1",
+	"dataVersion": "1"
+}]`, validator.URL)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Aeg-Event-Type", "SubscriptionValidation")
+			req.Header.Add("Content-Type", "application/json")
+
+			ctx := NewMockContext(req)
+			if err = subject(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := ctx.Status(), http.StatusOK; got != want {
+				t.Errorf("got status: %d want: %d", got, want)
+			}
+
+			select {
+			case err = <-results:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for validation to complete")
+			}
+
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("got error: %v want error: %v", err, tc.wantErr)
+			}
+
+			wantVisits := int32(2)
+			if tc.wantErr {
+				wantVisits = 0
+			}
+			if got := atomic.LoadInt32(&visits); got != wantVisits {
+				t.Errorf("got %d visits to the validation URL want: %d", got, wantVisits)
+			}
+		})
+	}
+}
+
+func TestApproveEventGridValidationURL(t *testing.T) {
+	testCases := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://rp-eastus2.eventgrid.azure.net:553/eventsubscriptions/estest/validate?id=512d38b6", false},
+		{"https://rp-eastus2.eventgrid.azure.net/eventsubscriptions/estest/validate?id=512d38b6", false},
+		{"https://RP-EASTUS2.EVENTGRID.AZURE.NET:443/validate", false},
+		{"http://rp-eastus2.eventgrid.azure.net/validate", true},
+		{"https://eventgrid.azure.net.contoso.com/validate", true},
+		{"https://contoso.com/?host=rp-eastus2.eventgrid.azure.net", true},
+		{"https://user@rp-eastus2.eventgrid.azure.net/validate", true},
+		{"http://169.254.169.254/metadata", true},
+		{"::not a url", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			err := eventgrid.ApproveEventGridValidationURL(eventgrid.Event{}, eventgrid.SubscriptionValidationRequest{ValidationURL: tc.url})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("got error: %v want error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package eventgrid
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gobuffalo/buffalo"
 )
@@ -15,29 +22,277 @@ import (
 // While the `EventHandler` interface does not itself has
 type TypeDispatchSubscriber struct {
 	Subscriber
-	bindings          map[string]EventHandler
+	bindings          map[string][]binding
+	patterns          []string
+	upcasters         map[string][]upcaster
+	middleware        []EventMiddleware
 	normalizeTypeCase bool
+	batchPolicy       BatchPolicy
+	deadLetterSink    DeadLetterSink
+	errorClassifier   ErrorClassifier
+	dispatchStrategy  DispatchStrategy
+	eventTimeout      time.Duration
+	batchTimeout      time.Duration
 }
 
 // NewTypeDispatchSubscriber initializes a new empty TypeDispathSubscriber.
 func NewTypeDispatchSubscriber(parent Subscriber) (created *TypeDispatchSubscriber) {
 	created = &TypeDispatchSubscriber{
 		Subscriber: parent,
-		bindings:   make(map[string]EventHandler),
+		bindings:   make(map[string][]binding),
+		upcasters:  make(map[string][]upcaster),
 	}
 	return
 }
 
+// binding holds an EventHandler, along with everything else that was specified while
+// binding it to an Event Type.
+type binding struct {
+	eventType  string
+	handler    EventHandler
+	middleware []EventMiddleware
+	subject    *subjectPattern
+	version    *versionConstraint
+	filter     EventMiddleware
+	schema     *Schema
+	dataType   reflect.Type
+}
+
+// eventHandler applies this binding's Filter, Schema, and EventMiddleware to its EventHandler.
+func (b binding) eventHandler() EventHandler {
+	handler := wrapEventHandler(b.handler, b.middleware...)
+	if b.schema != nil {
+		handler = SchemaMiddleware(b.schema)(handler)
+	}
+	if b.filter != nil {
+		handler = b.filter(handler)
+	}
+	return handler
+}
+
+// matches determines whether this binding should handle an Event with a particular Subject
+// and DataVersion, and reports the parameters captured from the Subject.
+func (b binding) matches(subject, dataVersion string) (map[string]string, bool) {
+	if b.version != nil && !b.version.allows(dataVersion) {
+		return nil, false
+	}
+	if b.subject == nil {
+		return nil, true
+	}
+	return b.subject.match(subject)
+}
+
+// sameSubject determines whether two bindings are restricted to the same Subjects.
+func (b binding) sameSubject(other binding) bool {
+	if b.subject == nil || other.subject == nil {
+		return b.subject == other.subject
+	}
+	return b.subject.raw == other.subject.raw
+}
+
+// sameVersion determines whether two bindings are restricted to the same DataVersions.
+func (b binding) sameVersion(other binding) bool {
+	if b.version == nil || other.version == nil {
+		return b.version == other.version
+	}
+	return b.version.raw == other.version.raw
+}
+
+// precedes determines whether this binding should be tried before another bound to the same
+// Event Type. Bindings restricted to Subjects come first, most specific first. Among those
+// restricted to the same Subjects, bindings restricted to DataVersions come first.
+func (b binding) precedes(other binding) bool {
+	switch {
+	case !b.sameSubject(other):
+		if other.subject == nil {
+			return true
+		}
+		return b.subject != nil && b.subject.precedes(other.subject)
+	default:
+		return b.version != nil && other.version == nil
+	}
+}
+
+// addBinding adds a binding to those already held for an Event Type, replacing any that was
+// restricted to the same Subjects and DataVersions. Bindings are kept in the order they should
+// be tried.
+func addBinding(existing []binding, b binding) []binding {
+	for i := range existing {
+		if existing[i].sameSubject(b) && existing[i].sameVersion(b) {
+			existing[i] = b
+			return existing
+		}
+	}
+
+	existing = append(existing, b)
+	sort.SliceStable(existing, func(i, j int) bool {
+		return existing[i].precedes(existing[j])
+	})
+	return existing
+}
+
+// BindOption customizes how an EventHandler is bound to an Event Type.
+type BindOption func(*binding)
+
+// WithMiddleware applies EventMiddleware to only the EventHandler being bound. It is run
+// after any EventMiddleware registered with `TypeDispatchSubscriber.Use`.
+func WithMiddleware(mw ...EventMiddleware) BindOption {
+	return func(b *binding) {
+		b.middleware = append(b.middleware, mw...)
+	}
+}
+
 // Bind ties together an Event Type identifier string and a function that knows how to handle it.
-func (s *TypeDispatchSubscriber) Bind(eventType string, handler EventHandler) *TypeDispatchSubscriber {
-	s.bindings[s.NormalizeEventType(eventType)] = handler
+//
+// The Event Type may also be a pattern, following the syntax of `path.Match`, in order to
+// handle a family of Event Types like "Microsoft.Storage.*". When more than one binding could
+// handle an Event, an exact match is preferred, then the pattern with the longest literal
+// prefix, and finally the `EventTypeWildcard`.
+//
+// The Event Type may be followed by "@" and a constraint on the DataVersion of the Events to
+// be handled, like "Contoso.Order.Created@2.0" or "Contoso.Order.Created@>=1.1 <2". See
+// `WithDataVersion` for the constraints which are understood. An EventHandler bound without a
+// constraint handles Events whose DataVersion is not handled by any other.
+func (s *TypeDispatchSubscriber) Bind(eventType string, handler EventHandler, opts ...BindOption) *TypeDispatchSubscriber {
+	eventType, version := splitDataVersion(eventType)
+	b := binding{eventType: eventType, handler: handler}
+
+	if version != "" {
+		WithDataVersion(version)(&b)
+	}
+
+	for _, opt := range opts {
+		opt(&b)
+	}
+
+	eventType = s.NormalizeEventType(eventType)
+	if _, ok := s.bindings[eventType]; !ok && isEventTypePattern(eventType) {
+		s.patterns = append(s.patterns, eventType)
+		sort.Slice(s.patterns, func(i, j int) bool {
+			return patternPrecedes(s.patterns[i], s.patterns[j])
+		})
+	}
+	s.bindings[eventType] = addBinding(s.bindings[eventType], b)
+	return s
+}
+
+// Use adds EventMiddleware which will be run for every Event received by this subscriber,
+// including those for which no EventHandler could be found.
+func (s *TypeDispatchSubscriber) Use(mw ...EventMiddleware) *TypeDispatchSubscriber {
+	s.middleware = append(s.middleware, mw...)
+	return s
+}
+
+// SetBatchPolicy changes how a batch of Events is answered, given the outcome of each
+// Event in it. By default, `FailWholeBatch` is used.
+func (s *TypeDispatchSubscriber) SetBatchPolicy(p BatchPolicy) *TypeDispatchSubscriber {
+	s.batchPolicy = p
+	return s
+}
+
+// SetDeadLetterSink changes where failed Events are forwarded when the `DeadLetterFailures`
+// or `DeadLetterPermanentFailures` policy is in use.
+func (s *TypeDispatchSubscriber) SetDeadLetterSink(sink DeadLetterSink) *TypeDispatchSubscriber {
+	s.deadLetterSink = sink
+	return s
+}
+
+// SetErrorClassifier changes how failed Events are judged to be worth retrying or not when
+// the `DeadLetterPermanentFailures` policy is in use. By default, `DefaultErrorClassifier`
+// is used.
+func (s *TypeDispatchSubscriber) SetErrorClassifier(classifier ErrorClassifier) *TypeDispatchSubscriber {
+	s.errorClassifier = classifier
+	return s
+}
+
+// ErrorClassifier gets the ErrorClassifier used to judge whether failed Events are worth
+// retrying.
+func (s TypeDispatchSubscriber) ErrorClassifier() ErrorClassifier {
+	if s.errorClassifier == nil {
+		return DefaultErrorClassifier
+	}
+	return s.errorClassifier
+}
+
+// SetDispatchStrategy changes the order in which, and how many at a time, the Events in a
+// batch are processed. By default, every Event in a batch is processed concurrently.
+func (s *TypeDispatchSubscriber) SetDispatchStrategy(d DispatchStrategy) *TypeDispatchSubscriber {
+	s.dispatchStrategy = d
+	return s
+}
+
+// DispatchStrategy gets the strategy used to process the Events in a batch.
+func (s TypeDispatchSubscriber) DispatchStrategy() DispatchStrategy {
+	if s.dispatchStrategy == nil {
+		return Concurrent()
+	}
+	return s.dispatchStrategy
+}
+
+// SetEventTimeout limits the amount of time each Event is given to be processed. Once it
+// has elapsed, the Event is recorded as having failed with an HTTP 504, though its Handler
+// keeps its place in the `DispatchStrategy` until it returns. By default, Events are only
+// limited by the deadline of the batch as a whole; see `SetBatchTimeout`.
+func (s *TypeDispatchSubscriber) SetEventTimeout(d time.Duration) *TypeDispatchSubscriber {
+	s.eventTimeout = d
+	return s
+}
+
+// EventTimeout gets the amount of time each Event is given to be processed. When zero, only
+// the deadline of the batch applies.
+func (s TypeDispatchSubscriber) EventTimeout() time.Duration {
+	if s.eventTimeout <= 0 {
+		return 0
+	}
+	return s.eventTimeout
+}
+
+// SetBatchTimeout changes the amount of time a batch of Events is given to be processed,
+// starting when it is received. Once it has elapsed, every Event in the batch that has not
+// been processed is recorded as having failed with an HTTP 504, and the batch is answered
+// without waiting for their Handlers to return. Events which have not yet been handed to a
+// Handler are not handed to one. By default, `DefaultBatchTimeout` is used.
+func (s *TypeDispatchSubscriber) SetBatchTimeout(d time.Duration) *TypeDispatchSubscriber {
+	s.batchTimeout = d
 	return s
 }
 
-// Unbind removes the mapping between an Event Type string and the associated EventHandler, if
-// such a mapping exists.
+// BatchTimeout gets the amount of time a batch of Events is given to be processed.
+func (s TypeDispatchSubscriber) BatchTimeout() time.Duration {
+	if s.batchTimeout <= 0 {
+		return DefaultBatchTimeout
+	}
+	return s.batchTimeout
+}
+
+// Unbind removes the mapping between an Event Type string and the associated EventHandlers, if
+// such a mapping exists. EventHandlers bound to that Event Type with any Subject pattern are
+// all removed. When the Event Type is followed by "@" and a DataVersion constraint, only the
+// EventHandlers bound with that same constraint are removed.
 func (s *TypeDispatchSubscriber) Unbind(eventType string) *TypeDispatchSubscriber {
-	delete(s.bindings, s.NormalizeEventType(eventType))
+	eventType, version := splitDataVersion(eventType)
+	eventType = s.NormalizeEventType(eventType)
+
+	if version != "" {
+		remaining := s.bindings[eventType][:0]
+		for _, b := range s.bindings[eventType] {
+			if b.version == nil || b.version.raw != strings.TrimSpace(version) {
+				remaining = append(remaining, b)
+			}
+		}
+		if len(remaining) > 0 {
+			s.bindings[eventType] = remaining
+			return s
+		}
+	}
+
+	delete(s.bindings, eventType)
+	for i, pattern := range s.patterns {
+		if pattern == eventType {
+			s.patterns = append(s.patterns[:i], s.patterns[i+1:]...)
+			break
+		}
+	}
 	return s
 }
 
@@ -54,45 +309,261 @@ func (s TypeDispatchSubscriber) NormalizeEventType(eventType string) string {
 // If handler for an Event's type is present, the event will be passed to that Handler.
 // Should no Handler be specifically bound to that Event Type string, a default Handler
 // is called.
-// When no Handler is found, even a default, an HTTP 400 Status Code is returned.
-// Each Event is handed to exactly one Handler. If even one of those handlers returns a
-// response code that is not an HTTP 200 OR 201, this handler will return an HTTP 500.
+// When no Handler is found, even a default, an HTTP 400 Status Code is recorded for that Event.
+// Events serialized using either the Event Grid schema or the CloudEvents v1.0 schema
+// are accepted. CloudEvents are triaged by their "type" attribute.
+// Every Event in the request is read using an `EventDecoder` before any of them is handled.
+// A request larger than `MaxPayloadSize` is answered with an HTTP 413, and an HTTP 413 is
+// recorded for each Event larger than `MaxEventSize` without handing it to a Handler.
+// Each Event is handed to exactly one Handler, along with its own child `Context` which
+// carries the Event, its index in the batch, and the deadline of the batch. A Handler which
+// panics, or does not return before that deadline, is recorded as having failed. The outcome
+// of each is recorded in a `BatchResult` which can be read using `BatchResultFromContext`,
+// next to the Events themselves, which can be read using `BatchEventsFromContext`. The batch
+// as a whole is then answered according to the subscriber's `BatchPolicy`, which by default
+// answers with an HTTP 500 when any Event was recorded with a status other than an HTTP 200 or
+// 201. Whenever the policy fails a batch in which an Event asked to be retried using
+// `RetryLater`, an HTTP 503 and a "Retry-After" header are returned instead, so that Event
+// Grid waits longer before delivering it again.
 func (s TypeDispatchSubscriber) Receive(c buffalo.Context) error {
-	var events []Event
+	received := time.Now()
 
-	if err := c.Bind(&events); err != nil {
-		return c.Error(http.StatusBadRequest, err)
+	events, oversized, err := readEvents(c.Request())
+	if err != nil {
+		return c.Error(readStatus(err), err)
 	}
+	c.Set(batchEventsKey, events)
+
+	batch, cancel := context.WithDeadline(c, received.Add(s.BatchTimeout()))
+	defer cancel()
 
 	ctx := NewContext(c)
-	var wg sync.WaitGroup
-	for _, event := range events {
-		wg.Add(1)
-		go func(event Event) {
-			if handler, ok := s.Handler(event.EventType); ok {
-				handler(ctx, event)
-			} else if handler, ok = s.Handler(EventTypeWildcard); ok {
-				handler(ctx, event)
-			} else {
-				ctx.Error(http.StatusBadRequest, fmt.Errorf("no Handler found for type %q", event.EventType))
+	ctx.ctx = batch
+
+	var settling sync.WaitGroup
+	settling.Add(len(events))
+	go s.DispatchStrategy().Dispatch(events, func(i int, event Event) {
+		ectx, cancel := ctx.newEventContext(event, i, s.EventTimeout())
+		defer cancel()
+
+		if err, ok := oversized[i]; ok {
+			ectx.finish(ectx.Error(http.StatusRequestEntityTooLarge, err))
+			settling.Done()
+			return
+		}
+		if batch.Err() != nil {
+			ectx.abandon()
+			settling.Done()
+			return
+		}
+		s.invoke(ectx, event, settling.Done)
+	})
+
+	settled := make(chan struct{})
+	go func() {
+		settling.Wait()
+		close(settled)
+	}()
+
+	select {
+	case <-settled:
+	case <-batch.Done():
+		for i, event := range events {
+			ectx, cancel := ctx.newEventContext(event, i, 0)
+			ectx.abandon()
+			cancel()
+		}
+	}
+
+	result := ctx.Results()
+	c.Set(batchResultKey, result)
+
+	classifier := s.ErrorClassifier()
+	status := s.batchPolicy.status(result, classifier)
+	if status == http.StatusOK && (s.batchPolicy == DeadLetterFailures || s.batchPolicy == DeadLetterPermanentFailures) {
+		if err := s.deadLetter(c, events, result, received); err != nil {
+			if logger := c.Logger(); logger != nil {
+				logger.Error(err)
 			}
-			wg.Done()
-		}(event)
+			status = http.StatusInternalServerError
+		}
+	}
+
+	if status != http.StatusOK && result.retryLater() {
+		status = http.StatusServiceUnavailable
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(RetryAfterDefault/time.Second)))
+	}
+
+	if status != http.StatusOK {
+		return c.Error(status, fmt.Errorf("%d of %d events in this batch failed to be processed", len(result.Failures()), len(result)))
+	}
+	c.Response().WriteHeader(status)
+	return nil
+}
+
+// invoke hands an Event to the appropriate Handler, once any Upcasters registered for it
+// have been applied, and records the outcome. Should the Handler panic, an HTTP 500 is
+// recorded. Should the Handler not return before the Context's deadline, an HTTP 504 is
+// recorded straight away, but invoke does not return until the Handler has, so that a
+// DispatchStrategy never has more Handlers running than it allows. Either way, settle is
+// called as soon as the outcome of the Event is final.
+func (s TypeDispatchSubscriber) invoke(c *Context, e Event, settle func()) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- handlePanic(c, r)
+			}
+		}()
+
+		var handler EventHandler
+		if upcast, err := s.upcast(e); err != nil {
+			handler = func(c buffalo.Context, e Event) error {
+				return c.Error(http.StatusBadRequest, fmt.Errorf("unable to upcast event %q: %v", e.ID, err))
+			}
+		} else if b, params, ok := s.route(upcast); ok {
+			e = upcast
+			c.params = params
+			handler = b.eventHandler()
+		} else {
+			handler = func(c buffalo.Context, e Event) error {
+				return c.Error(http.StatusBadRequest, fmt.Errorf("no Handler found for type %q", e.EventType))
+			}
+		}
+
+		done <- wrapEventHandler(handler, s.middleware...)(c, e)
+	}()
+
+	select {
+	case err := <-done:
+		c.finish(err)
+		settle()
+	case <-c.Done():
+		c.abandon()
+		settle()
+		<-done
+	}
+}
+
+// handlePanic records an HTTP 500 for a Handler which panicked, and logs the stack
+// trace of the panic. It must be called from the function deferred by the panicking
+// goroutine.
+func handlePanic(c buffalo.Context, recovered interface{}) error {
+	if logger := c.Logger(); logger != nil {
+		logger.Errorf("event handler panicked: %v\n%s", recovered, debug.Stack())
+	}
+	return c.Error(http.StatusInternalServerError, fmt.Errorf("event handler panicked: %v", recovered))
+}
+
+// deadLetter forwards each failed Event in a batch to this subscriber's DeadLetterSink.
+func (s TypeDispatchSubscriber) deadLetter(c buffalo.Context, events []Event, result BatchResult, received time.Time) error {
+	failures := result.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+
+	if s.deadLetterSink == nil {
+		return errors.New("no DeadLetterSink has been configured")
 	}
-	wg.Wait()
 
-	if ctx.ResponseHasFailure() {
-		return c.Error(http.StatusInternalServerError, errors.New("at least one handler failed to process an event in this batch"))
+	delivery := DeliveryInfoFromContext(c)
+	for i, event := range events {
+		failure, ok := failures.At(i)
+		if !ok {
+			continue
+		}
+
+		letter := DeadLetter{
+			Event:         event,
+			Result:        failure,
+			DeliveryCount: delivery.DeliveryCount,
+			ReceivedAt:    received,
+		}
+		if err := s.deadLetterSink.Send(c, letter); err != nil {
+			return fmt.Errorf("unable to dead-letter event %q: %v", event.ID, err)
+		}
 	}
-	c.Response().WriteHeader(http.StatusOK)
 	return nil
 }
 
-// Handler gets the EventHandler meant to process a particular Event Grid Event Type.
+// Handler gets the EventHandler meant to process a particular Event Grid Event Type. Should
+// no EventHandler be bound to exactly that Event Type, the most specific matching pattern
+// is used instead. The Event Type may be followed by "@" and a DataVersion, in order to find
+// the EventHandler for Events carrying that DataVersion. EventHandlers bound using
+// `WithSubject` are not considered. Any EventMiddleware specified while binding it has
+// already been applied.
 func (s TypeDispatchSubscriber) Handler(eventType string) (handler EventHandler, ok bool) {
-	if s.normalizeTypeCase {
-		eventType = strings.ToUpper(eventType)
+	eventType, version := splitDataVersion(eventType)
+	for _, candidate := range s.candidates(eventType) {
+		for _, b := range s.bindings[candidate] {
+			if b.subject != nil {
+				continue
+			}
+			if _, ok := b.matches("", version); ok {
+				return b.eventHandler(), true
+			}
+		}
 	}
-	handler, ok = s.bindings[eventType]
-	return
+	return nil, false
+}
+
+// route finds the binding which should process an Event, considering its Type, Subject, and
+// DataVersion, and reports any parameters captured from the Subject.
+func (s TypeDispatchSubscriber) route(e Event) (binding, map[string]string, bool) {
+	for _, candidate := range append(s.candidates(e.EventType), s.NormalizeEventType(EventTypeWildcard)) {
+		for _, b := range s.bindings[candidate] {
+			if params, ok := b.matches(e.Subject, e.DataVersion); ok {
+				return b, params, true
+			}
+		}
+	}
+	return binding{}, nil, false
+}
+
+// candidates lists the keys of each set of bindings which could handle an Event Type, most
+// specific first.
+func (s TypeDispatchSubscriber) candidates(eventType string) []string {
+	eventType = s.NormalizeEventType(eventType)
+
+	var found []string
+	if _, ok := s.bindings[eventType]; ok {
+		found = append(found, eventType)
+	}
+	for _, pattern := range s.patterns {
+		if pattern == eventType {
+			continue
+		}
+		if matched, _ := path.Match(pattern, eventType); matched {
+			found = append(found, pattern)
+		}
+	}
+	return found
+}
+
+// isEventTypePattern determines whether an Event Type string should be treated as a pattern
+// matching many Event Types, rather than an identifier of a single one.
+func isEventTypePattern(eventType string) bool {
+	return strings.ContainsAny(eventType, `*?[\`)
+}
+
+// literalPrefix finds the portion of a pattern which must be matched exactly.
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// patternPrecedes determines whether pattern a should be tried before pattern b. Patterns with
+// longer literal prefixes are more specific, so they are preferred. Remaining ties are broken by
+// overall length, and then alphabetically, so that the outcome never depends on the order in
+// which patterns were bound.
+func patternPrecedes(a, b string) bool {
+	if la, lb := len(literalPrefix(a)), len(literalPrefix(b)); la != lb {
+		return la > lb
+	}
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a < b
 }
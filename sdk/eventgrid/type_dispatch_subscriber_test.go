@@ -2,8 +2,13 @@ package eventgrid_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 
 	"github.com/Azure/buffalo-azure/sdk/eventgrid"
 	"github.com/gobuffalo/buffalo"
@@ -56,3 +61,219 @@ func ExampleTypeDispatchSubscriber_Receive() {
 
 	// Output: 831e1650-001e-001b-66ab-eeb76e069631
 }
+
+func TestTypeDispatchSubscriber_Receive_Panic(t *testing.T) {
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Contoso.Panic", func(c buffalo.Context, e eventgrid.Event) error {
+			panic("unexpected payload")
+		}).
+		Bind("Contoso.Succeed", func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Panic"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	if r, ok := result.Get("0"); !ok || !r.Succeeded() {
+		t.Errorf("event alongside a panicking handler got: %+v", r)
+	}
+	if r, ok := result.Get("1"); !ok || r.Status != http.StatusInternalServerError || r.Err == nil {
+		t.Errorf("event with a panicking handler got: %+v", r)
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		SetEventTimeout(20*time.Millisecond).
+		Bind("Contoso.Hang", func(c buffalo.Context, e eventgrid.Event) error {
+			<-release
+			return nil
+		}).
+		Bind("Contoso.Succeed", func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Succeed", "Contoso.Hang"))
+
+	start := time.Now()
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Receive waited %v for a hanging handler", elapsed)
+	}
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	if r, ok := result.Get("0"); !ok || !r.Succeeded() {
+		t.Errorf("event alongside a hanging handler got: %+v", r)
+	}
+	if r, ok := result.Get("1"); !ok || r.Status != http.StatusGatewayTimeout {
+		t.Errorf("event with a hanging handler got: %+v", r)
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_TimeoutHoldsWorker(t *testing.T) {
+	var running, most int32
+	var finished sync.WaitGroup
+	finished.Add(4)
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		SetDispatchStrategy(eventgrid.WorkerPool(1)).
+		SetEventTimeout(20*time.Millisecond).
+		Bind("Contoso.Slow", func(c buffalo.Context, e eventgrid.Event) error {
+			defer finished.Done()
+
+			now := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				seen := atomic.LoadInt32(&most)
+				if now <= seen || atomic.CompareAndSwapInt32(&most, seen, now) {
+					break
+				}
+			}
+
+			time.Sleep(40 * time.Millisecond)
+			return nil
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Slow", "Contoso.Slow", "Contoso.Slow", "Contoso.Slow"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	finished.Wait()
+
+	if got := atomic.LoadInt32(&most); got != 1 {
+		t.Errorf("got %d handlers running at once want: 1", got)
+	}
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	for _, r := range result {
+		if r.Status != http.StatusGatewayTimeout {
+			t.Errorf("event %q got status: %d want: %d", r.ID, r.Status, http.StatusGatewayTimeout)
+		}
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_BatchTimeout(t *testing.T) {
+	var handled int32
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		SetDispatchStrategy(eventgrid.Sequential()).
+		SetBatchTimeout(50*time.Millisecond).
+		Bind("Contoso.Slow", func(c buffalo.Context, e eventgrid.Event) error {
+			atomic.AddInt32(&handled, 1)
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Slow", "Contoso.Slow", "Contoso.Slow", "Contoso.Slow"))
+
+	start := time.Now()
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Receive answered after %v want the batch deadline to be respected", elapsed)
+	}
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	want := map[string]int{
+		"0": http.StatusOK,
+		"1": http.StatusGatewayTimeout,
+		"2": http.StatusGatewayTimeout,
+		"3": http.StatusGatewayTimeout,
+	}
+	for id, status := range want {
+		if r, ok := result.Get(id); !ok || r.Status != status {
+			t.Errorf("event %q got: %+v want status: %d", id, r, status)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&handled); got != 2 {
+		t.Errorf("got %d events handed to a handler want: 2", got)
+	}
+}
+
+func TestTypeDispatchSubscriber_Handler_Patterns(t *testing.T) {
+	named := func(name string) eventgrid.EventHandler {
+		return func(buffalo.Context, eventgrid.Event) error {
+			return errors.New(name)
+		}
+	}
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind(eventgrid.EventTypeWildcard, named("wildcard")).
+		Bind("*", named("star")).
+		Bind("Microsoft.*", named("microsoft")).
+		Bind("Microsoft.Storage.*", named("storage")).
+		Bind("Microsoft.Storage.Blob*", named("blob")).
+		Bind("Microsoft.Storage.*Deleted", named("deleted")).
+		Bind("Microsoft.Storage.BlobCreated", named("created")).
+		Bind("Microsoft.Resources.ResourceWrite*", named("write")).
+		Bind("Microsoft.Resources.Resource*", named("resource-b")).
+		Bind("Microsoft.Resources.Resource?rite*", named("resource-a"))
+
+	testCases := []struct {
+		eventType string
+		want      string
+	}{
+		{"Microsoft.Storage.BlobCreated", "created"},
+		{"Microsoft.Storage.BlobDeleted", "blob"},
+		{"Microsoft.Storage.DirectoryDeleted", "deleted"},
+		{"Microsoft.Storage.DirectoryCreated", "storage"},
+		{"Microsoft.Resources.ResourceWriteSuccess", "write"},
+		{"Microsoft.Resources.ResourceDeleteSuccess", "resource-b"},
+		{"Microsoft.Resources.ResourceActionSuccess", "resource-b"},
+		{"Microsoft.KeyVault.SecretNewVersionCreated", "microsoft"},
+		{"Contoso.Items.ItemReceived", "star"},
+		{eventgrid.EventTypeWildcard, "wildcard"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.eventType, func(t *testing.T) {
+			handler, ok := subject.Handler(tc.eventType)
+			if !ok {
+				t.Fatal("no Handler was found")
+			}
+			if got := handler(nil, eventgrid.Event{}).Error(); got != tc.want {
+				t.Errorf("got: %q want: %q", got, tc.want)
+			}
+		})
+	}
+
+	subject.Unbind("*").Unbind("Microsoft.*")
+	if _, ok := subject.Handler("Contoso.Items.ItemReceived"); ok {
+		t.Error("unbound pattern was still matched")
+	}
+	if _, ok := subject.Handler("Microsoft.KeyVault.SecretNewVersionCreated"); ok {
+		t.Error("unbound pattern was still matched")
+	}
+}
+
+func TestTypeDispatchSubscriber_Receive_Patterns(t *testing.T) {
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		Bind("Contoso.Items.*", func(c buffalo.Context, e eventgrid.Event) error {
+			return nil
+		}).
+		Bind(eventgrid.EventTypeWildcard, func(c buffalo.Context, e eventgrid.Event) error {
+			return c.Error(http.StatusBadRequest, errors.New("unexpected event"))
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Items.ItemReceived", "Contoso.Orders.OrderPlaced"))
+	receiveStatus(subject, ctx)
+
+	result, _ := eventgrid.BatchResultFromContext(ctx)
+	if r, _ := result.Get("0"); r.Status != http.StatusOK {
+		t.Errorf("pattern-bound event got status: %d want: %d", r.Status, http.StatusOK)
+	}
+	if r, _ := result.Get("1"); r.Status != http.StatusBadRequest {
+		t.Errorf("wildcard-bound event got status: %d want: %d", r.Status, http.StatusBadRequest)
+	}
+}
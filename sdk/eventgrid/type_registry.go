@@ -0,0 +1,191 @@
+package eventgrid
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TypeRegistry maps Event Types, and the DataVersion of the data they carry, to the Go types
+// that their data should be read into. Event Types are matched without regard to case, as they
+// are by Event Grid.
+//
+// The zero value is an empty TypeRegistry ready for use.
+type TypeRegistry struct {
+	lock    sync.RWMutex
+	entries map[string][]registeredType
+}
+
+type registeredType struct {
+	eventType   string
+	dataVersion string
+	version     *versionConstraint
+	dataType    reflect.Type
+}
+
+// UnregisteredEventTypeError is reported when data is decoded for an Event whose Event Type
+// and DataVersion have not been registered.
+type UnregisteredEventTypeError struct {
+	EventType   string
+	DataVersion string
+}
+
+func (err UnregisteredEventTypeError) Error() string {
+	if err.DataVersion == "" {
+		return fmt.Sprintf("no type has been registered for event type %q", err.EventType)
+	}
+	return fmt.Sprintf("no type has been registered for event type %q with data version %q", err.EventType, err.DataVersion)
+}
+
+// DefaultTypeRegistry is the TypeRegistry used by `Event.DecodeData`. It is created by
+// `NewTypeRegistry`, so the Event Types published by Azure services are already registered.
+var DefaultTypeRegistry = NewTypeRegistry()
+
+// NewTypeRegistry creates a TypeRegistry in which the Event Types published by Azure services
+// are already registered, using the types found in the package
+// "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid".
+func NewTypeRegistry() *TypeRegistry {
+	created := &TypeRegistry{}
+	for eventType, prototype := range wellKnownEvents {
+		created.Register(eventType, "", prototype)
+	}
+	return created
+}
+
+// Register associates an Event Type and DataVersion with the type of prototype, which may be
+// either a value or a pointer to a value of that type. The DataVersion may also be a constraint
+// satisfied by many DataVersions, like "^1" or ">=2.0 <3"; see `WithDataVersion` for the
+// constraints which are understood. When dataVersion is empty, the type is used for every
+// DataVersion which has not been registered separately. Registering the same Event Type and
+// DataVersion again replaces the type previously associated with them.
+//
+// Register panics if eventType is empty, dataVersion is not a valid constraint, or prototype
+// is nil.
+func (r *TypeRegistry) Register(eventType, dataVersion string, prototype interface{}) *TypeRegistry {
+	if eventType == "" {
+		panic("eventgrid: event type must not be empty")
+	}
+	if prototype == nil {
+		panic(fmt.Sprintf("eventgrid: type registered for event type %q must not be nil", eventType))
+	}
+
+	var version *versionConstraint
+	if dataVersion != "" {
+		var err error
+		if version, err = parseVersionConstraint(dataVersion); err != nil {
+			panic(err)
+		}
+	}
+
+	dataType := reflect.TypeOf(prototype)
+	if dataType.Kind() == reflect.Ptr {
+		dataType = dataType.Elem()
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.entries == nil {
+		r.entries = make(map[string][]registeredType)
+	}
+
+	key := strings.ToLower(eventType)
+	entry := registeredType{
+		eventType:   eventType,
+		dataVersion: dataVersion,
+		version:     version,
+		dataType:    dataType,
+	}
+
+	for i, existing := range r.entries[key] {
+		if existing.dataVersion == dataVersion {
+			r.entries[key][i] = entry
+			return r
+		}
+	}
+	r.entries[key] = append(r.entries[key], entry)
+	return r
+}
+
+// Unregister removes every type associated with an Event Type.
+func (r *TypeRegistry) Unregister(eventType string) *TypeRegistry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.entries, strings.ToLower(eventType))
+	return r
+}
+
+// Lookup finds the type associated with an Event Type and DataVersion. A type registered for
+// exactly that DataVersion is preferred, followed by the first registered with a constraint
+// the DataVersion satisfies. Should there be neither, the type registered for every
+// DataVersion of the Event Type is returned instead.
+func (r *TypeRegistry) Lookup(eventType, dataVersion string) (reflect.Type, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var constrained, fallback reflect.Type
+	for _, entry := range r.entries[strings.ToLower(eventType)] {
+		switch {
+		case entry.dataVersion == dataVersion:
+			return entry.dataType, true
+		case entry.version == nil:
+			fallback = entry.dataType
+		case constrained == nil && entry.version.allows(dataVersion):
+			constrained = entry.dataType
+		}
+	}
+
+	if constrained != nil {
+		return constrained, true
+	}
+	return fallback, fallback != nil
+}
+
+// EventTypes lists each Event Type which has been registered, in alphabetical order.
+func (r *TypeRegistry) EventTypes() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	eventTypes := make([]string, 0, len(r.entries))
+	for _, entries := range r.entries {
+		if len(entries) > 0 {
+			eventTypes = append(eventTypes, entries[0].eventType)
+		}
+	}
+	sort.Strings(eventTypes)
+	return eventTypes
+}
+
+// Decode reads the data carried by an Event into a new value of the type associated with its
+// Event Type and DataVersion, and returns a pointer to it. Should no type have been
+// registered, an `UnregisteredEventTypeError` is returned. Should the data not fit the
+// registered type, a `DataDecodeError` is returned.
+func (r *TypeRegistry) Decode(e Event) (interface{}, error) {
+	dataType, ok := r.Lookup(e.EventType, e.DataVersion)
+	if !ok {
+		return nil, UnregisteredEventTypeError{
+			EventType:   e.EventType,
+			DataVersion: e.DataVersion,
+		}
+	}
+
+	payload := reflect.New(dataType)
+	if err := e.UnmarshalData(payload.Interface()); err != nil {
+		return nil, DataDecodeError{
+			EventID:   e.ID,
+			EventType: e.EventType,
+			Target:    dataType,
+			Err:       err,
+		}
+	}
+	return payload.Interface(), nil
+}
+
+// DecodeData reads the data carried by an Event into the type that `DefaultTypeRegistry`
+// associates with its Event Type and DataVersion. A pointer to the new value is returned.
+func (e Event) DecodeData() (interface{}, error) {
+	return DefaultTypeRegistry.Decode(e)
+}
@@ -0,0 +1,128 @@
+package eventgrid_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	azeg "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+)
+
+func ExampleEvent_DecodeData() {
+	e := eventgrid.Event{
+		ID:        "1",
+		EventType: "Microsoft.Storage.BlobCreated",
+		Data:      json.RawMessage(`{"api": "PutBlockList", "url": "https://contoso.blob.core.windows.net/photos/cat.jpg"}`),
+	}
+
+	data, err := e.DecodeData()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	switch payload := data.(type) {
+	case *azeg.StorageBlobCreatedEventData:
+		fmt.Println(*payload.API, *payload.URL)
+	default:
+		fmt.Printf("unexpected type %T\n", payload)
+	}
+
+	// Output: PutBlockList https://contoso.blob.core.windows.net/photos/cat.jpg
+}
+
+func TestNewTypeRegistry_WellKnown(t *testing.T) {
+	subject := eventgrid.NewTypeRegistry()
+
+	testCases := []struct {
+		eventType string
+		want      reflect.Type
+	}{
+		{"Microsoft.Storage.BlobCreated", reflect.TypeOf(azeg.StorageBlobCreatedEventData{})},
+		{"microsoft.storage.blobdeleted", reflect.TypeOf(azeg.StorageBlobDeletedEventData{})},
+		{"Microsoft.Resources.ResourceWriteSuccess", reflect.TypeOf(azeg.ResourceWriteSuccessData{})},
+		{"Microsoft.EventGrid.SubscriptionValidationEvent", reflect.TypeOf(azeg.SubscriptionValidationEventData{})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.eventType, func(t *testing.T) {
+			got, ok := subject.Lookup(tc.eventType, "1")
+			if !ok {
+				t.Fatal("event type was not registered")
+			}
+			if got != tc.want {
+				t.Errorf("got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, ok := subject.Lookup("Contoso.Unknown", ""); ok {
+		t.Error("unexpected type registered for Contoso.Unknown")
+	}
+}
+
+func TestTypeRegistry_DataVersion(t *testing.T) {
+	type v1 struct{ Name string }
+	type v2 struct{ FullName string }
+
+	var subject eventgrid.TypeRegistry
+	subject.Register("Contoso.Created", "", v1{}).Register("Contoso.Created", "2.0", &v2{})
+
+	testCases := []struct {
+		dataVersion string
+		want        reflect.Type
+	}{
+		{"", reflect.TypeOf(v1{})},
+		{"1.0", reflect.TypeOf(v1{})},
+		{"2.0", reflect.TypeOf(v2{})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dataVersion, func(t *testing.T) {
+			got, ok := subject.Lookup("Contoso.Created", tc.dataVersion)
+			if !ok {
+				t.Fatal("no type was found")
+			}
+			if got != tc.want {
+				t.Errorf("got: %v want: %v", got, tc.want)
+			}
+		})
+	}
+
+	subject.Unregister("contoso.created")
+	if got := subject.EventTypes(); len(got) != 0 {
+		t.Errorf("got event types: %v want none", got)
+	}
+}
+
+func TestTypeRegistry_Decode(t *testing.T) {
+	type payload struct {
+		Index int `json:"index"`
+	}
+
+	var subject eventgrid.TypeRegistry
+	subject.Register("Contoso.Indexed", "", payload{})
+
+	decoded, err := subject.Decode(eventgrid.Event{ID: "1", EventType: "Contoso.Indexed", Data: json.RawMessage(`{"index": 4}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := decoded.(*payload); !ok || got.Index != 4 {
+		t.Errorf("unexpected decoded value: %#v", decoded)
+	}
+
+	_, err = subject.Decode(eventgrid.Event{ID: "2", EventType: "Contoso.Indexed", Data: json.RawMessage(`{"index": "four"}`)})
+	if _, ok := err.(eventgrid.DataDecodeError); !ok {
+		t.Errorf("got error %T want: eventgrid.DataDecodeError", err)
+	}
+
+	_, err = subject.Decode(eventgrid.Event{ID: "3", EventType: "Contoso.Unknown", DataVersion: "1.0"})
+	if got, ok := err.(eventgrid.UnregisteredEventTypeError); !ok {
+		t.Errorf("got error %T want: eventgrid.UnregisteredEventTypeError", err)
+	} else if got.EventType != "Contoso.Unknown" || got.DataVersion != "1.0" {
+		t.Errorf("unexpected error: %v", got)
+	}
+}
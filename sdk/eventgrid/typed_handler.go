@@ -0,0 +1,151 @@
+package eventgrid
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+var (
+	buffaloContextType = reflect.TypeOf((*buffalo.Context)(nil)).Elem()
+	eventStructType    = reflect.TypeOf(Event{})
+	errorInterfaceType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// DataDecodeError is reported when the "data" property of an Event could not be read into the
+// type expected by its handler.
+type DataDecodeError struct {
+	EventID   string
+	EventType string
+	Target    reflect.Type
+	Err       error
+}
+
+func (err DataDecodeError) Error() string {
+	return fmt.Sprintf("unable to read data of event %q (%s) as %v: %v", err.EventID, err.EventType, err.Target, err.Err)
+}
+
+// Cause fetches the error which prevented the data from being read.
+func (err DataDecodeError) Cause() error {
+	return err.Err
+}
+
+// Permanent indicates that delivering an Event again will not help it be read.
+func (DataDecodeError) Permanent() bool {
+	return true
+}
+
+// NewTypedEventHandler adapts a strongly typed function into an EventHandler. The function must
+// have the signature:
+//
+//	func(buffalo.Context, eventgrid.Event, *T) error
+//
+// where T is the type that the "data" property of each Event will be read into. T may also be
+// accepted by value instead of by pointer. When the data cannot be read, the function is not
+// called, and the Event is reported with an HTTP 400 Status Code and a `DataDecodeError`.
+func NewTypedEventHandler(fn interface{}) (EventHandler, error) {
+	if h, ok := fn.(EventHandler); ok {
+		return h, nil
+	}
+	if h, ok := fn.(func(buffalo.Context, Event) error); ok {
+		return h, nil
+	}
+
+	dataType, err := typedHandlerDataType(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	target, byValue := dataType, false
+	if dataType.Kind() != reflect.Ptr {
+		target = reflect.PtrTo(dataType)
+		byValue = true
+	}
+
+	callable := reflect.ValueOf(fn)
+	return func(c buffalo.Context, e Event) error {
+		payload := reflect.New(target.Elem())
+		if err := e.UnmarshalData(payload.Interface()); err != nil {
+			return c.Error(http.StatusBadRequest, DataDecodeError{
+				EventID:   e.ID,
+				EventType: e.EventType,
+				Target:    dataType,
+				Err:       err,
+			})
+		}
+
+		if byValue {
+			payload = payload.Elem()
+		}
+
+		results := callable.Call([]reflect.Value{reflect.ValueOf(&c).Elem(), reflect.ValueOf(e), payload})
+		if failure, _ := results[0].Interface().(error); failure != nil {
+			return failure
+		}
+		return nil
+	}, nil
+}
+
+// typedHandlerDataType ensures that fn has the signature required by `NewTypedEventHandler`,
+// and fetches the type of its third parameter.
+func typedHandlerDataType(fn interface{}) (reflect.Type, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("typed event handler must not be nil")
+	}
+
+	t := reflect.TypeOf(fn)
+	if t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("typed event handler must be a function, not %v", t)
+	}
+
+	if reflect.ValueOf(fn).IsNil() {
+		return nil, fmt.Errorf("typed event handler must not be nil")
+	}
+
+	if t.IsVariadic() || t.NumIn() != 3 || t.NumOut() != 1 {
+		return nil, fmt.Errorf("typed event handler %v must have the signature func(buffalo.Context, eventgrid.Event, *T) error", t)
+	}
+
+	if t.In(0) != buffaloContextType {
+		return nil, fmt.Errorf("first parameter of typed event handler %v must be a buffalo.Context", t)
+	}
+
+	if t.In(1) != eventStructType {
+		return nil, fmt.Errorf("second parameter of typed event handler %v must be an eventgrid.Event", t)
+	}
+
+	if t.Out(0) != errorInterfaceType {
+		return nil, fmt.Errorf("typed event handler %v must return an error", t)
+	}
+
+	dataType := t.In(2)
+	if dataType.Kind() == reflect.Ptr && dataType.Elem().Kind() == reflect.Ptr {
+		return nil, fmt.Errorf("third parameter of typed event handler %v must not be a pointer to a pointer", t)
+	}
+	return dataType, nil
+}
+
+// BindTyped ties together an Event Type identifier string and a strongly typed function that
+// knows how to handle it, as described by `NewTypedEventHandler`. The signature of the function
+// is checked immediately, and BindTyped panics if it is not supported.
+func (s *TypeDispatchSubscriber) BindTyped(eventType string, fn interface{}, opts ...BindOption) *TypeDispatchSubscriber {
+	handler, err := NewTypedEventHandler(fn)
+	if err != nil {
+		panic(err)
+	}
+
+	if dataType, err := typedHandlerDataType(fn); err == nil {
+		opts = append([]BindOption{withDataType(dataType)}, opts...)
+	}
+	return s.Bind(eventType, handler, opts...)
+}
+
+// withDataType records the type that a handler reads the data of each Event into, so that
+// sample Events can be created for it.
+func withDataType(t reflect.Type) BindOption {
+	return func(b *binding) {
+		b.dataType = t
+	}
+}
@@ -0,0 +1,177 @@
+package eventgrid_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+type indexedData struct {
+	Index int `json:"index"`
+}
+
+func ExampleTypeDispatchSubscriber_BindTyped() {
+	type BlobCreated struct {
+		URL string `json:"url"`
+	}
+
+	subscriber := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		BindTyped("Microsoft.Storage.BlobCreated", func(c buffalo.Context, e eventgrid.Event, payload *BlobCreated) error {
+			fmt.Println("created", payload.URL)
+			return nil
+		})
+
+	req, err := http.NewRequest(http.MethodPost, "localhost", strings.NewReader(`[{"id": "1", "eventType": "Microsoft.Storage.BlobCreated", "data": {"url": "https://contoso.blob.core.windows.net/photos/cat.jpg"}}]`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if err = subscriber.Receive(NewMockContext(req)); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output: created https://contoso.blob.core.windows.net/photos/cat.jpg
+}
+
+func TestNewTypedEventHandler_Signatures(t *testing.T) {
+	testCases := []struct {
+		name  string
+		fn    interface{}
+		valid bool
+	}{
+		{"pointer", func(buffalo.Context, eventgrid.Event, *indexedData) error { return nil }, true},
+		{"value", func(buffalo.Context, eventgrid.Event, indexedData) error { return nil }, true},
+		{"map", func(buffalo.Context, eventgrid.Event, map[string]interface{}) error { return nil }, true},
+		{"untyped", func(buffalo.Context, eventgrid.Event) error { return nil }, true},
+		{"event handler", eventgrid.EventHandler(func(buffalo.Context, eventgrid.Event) error { return nil }), true},
+		{"nil", nil, false},
+		{"nil func", (func(buffalo.Context, eventgrid.Event, *indexedData) error)(nil), false},
+		{"not a func", indexedData{}, false},
+		{"missing data", func(buffalo.Context, *indexedData) error { return nil }, false},
+		{"wrong context", func(*eventgrid.Context, eventgrid.Event, *indexedData) error { return nil }, false},
+		{"wrong event", func(buffalo.Context, *eventgrid.Event, *indexedData) error { return nil }, false},
+		{"no error", func(buffalo.Context, eventgrid.Event, *indexedData) {}, false},
+		{"extra result", func(buffalo.Context, eventgrid.Event, *indexedData) (int, error) { return 0, nil }, false},
+		{"wrong result", func(buffalo.Context, eventgrid.Event, *indexedData) bool { return false }, false},
+		{"double pointer", func(buffalo.Context, eventgrid.Event, **indexedData) error { return nil }, false},
+		{"variadic", func(buffalo.Context, eventgrid.Event, ...indexedData) error { return nil }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, err := eventgrid.NewTypedEventHandler(tc.fn)
+			if tc.valid {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				} else if handler == nil {
+					t.Error("no handler was returned")
+				}
+			} else if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTypeDispatchSubscriber_BindTyped_Invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		BindTyped("Contoso.Typed", func(c buffalo.Context, payload *indexedData) error { return nil })
+}
+
+func TestTypeDispatchSubscriber_BindTyped_Receive(t *testing.T) {
+	var byPointer, byValue []int
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		BindTyped("Contoso.Pointer", func(c buffalo.Context, e eventgrid.Event, payload *indexedData) error {
+			byPointer = append(byPointer, payload.Index)
+			return nil
+		}).
+		BindTyped("Contoso.Value", func(c buffalo.Context, e eventgrid.Event, payload indexedData) error {
+			byValue = append(byValue, payload.Index)
+			return nil
+		}).
+		BindTyped("Contoso.Fail", func(c buffalo.Context, e eventgrid.Event, payload *indexedData) error {
+			return errors.New("failed")
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Pointer", "Contoso.Value", "Contoso.Fail"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if len(byPointer) != 1 || byPointer[0] != 0 {
+		t.Errorf("pointer handler saw: %v", byPointer)
+	}
+
+	if len(byValue) != 1 || byValue[0] != 1 {
+		t.Errorf("value handler saw: %v", byValue)
+	}
+
+	result, ok := eventgrid.BatchResultFromContext(ctx)
+	if !ok {
+		t.Fatal("no BatchResult was associated with the Context")
+	}
+
+	if r, ok := result.Get("2"); !ok || r.Status != http.StatusInternalServerError || r.Err == nil || r.Err.Error() != "failed" {
+		t.Errorf("unexpected result for failing handler: %+v", r)
+	}
+}
+
+func TestTypeDispatchSubscriber_BindTyped_DecodeFailure(t *testing.T) {
+	called := false
+
+	subject := eventgrid.NewTypeDispatchSubscriber(eventgrid.BaseSubscriber{}).
+		BindTyped("Contoso.Typed", func(c buffalo.Context, e eventgrid.Event, payload *struct{ Index string }) error {
+			called = true
+			return nil
+		})
+
+	ctx := NewMockContext(newBatchRequest(t, "Contoso.Typed"))
+	if got, want := receiveStatus(subject, ctx), http.StatusInternalServerError; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if called {
+		t.Error("handler should not have been called")
+	}
+
+	result, ok := eventgrid.BatchResultFromContext(ctx)
+	if !ok {
+		t.Fatal("no BatchResult was associated with the Context")
+	}
+
+	r, ok := result.Get("0")
+	if !ok {
+		t.Fatal("no result for event \"0\"")
+	}
+
+	if r.Status != http.StatusBadRequest {
+		t.Errorf("got status: %d want: %d", r.Status, http.StatusBadRequest)
+	}
+
+	decodeErr, ok := r.Err.(eventgrid.DataDecodeError)
+	if !ok {
+		t.Fatalf("got error %T want: eventgrid.DataDecodeError", r.Err)
+	}
+
+	if decodeErr.EventID != "0" || decodeErr.EventType != "Contoso.Typed" {
+		t.Errorf("unexpected error: %v", decodeErr)
+	}
+
+	if !eventgrid.IsPermanent(r.Err) || !eventgrid.DefaultErrorClassifier.Permanent(r) {
+		t.Error("decode failures should be permanent")
+	}
+}
@@ -0,0 +1,124 @@
+package eventgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Upcaster converts an Event whose data was published using an older DataVersion into one
+// carrying data in the shape of a newer DataVersion, so that only EventHandlers for the
+// newest DataVersion need to be written. See `UpcastData` for an easy way to write one.
+type Upcaster func(Event) (Event, error)
+
+type upcaster struct {
+	version *versionConstraint
+	convert Upcaster
+}
+
+// Upcast registers an Upcaster for an Event Type. The Event Type should be followed by "@" and
+// a constraint describing the DataVersions to be converted, like "Contoso.Order.Created@1.x".
+// See `WithDataVersion` for the constraints which are understood.
+//
+// Before an Event is handed to an EventHandler, each Upcaster registered for its Event Type
+// whose constraint is satisfied by the Event's DataVersion is applied, in the order they were
+// registered. Each Upcaster is applied at most once, so upcasting from "1.0" to "2.0" and then
+// from "2.0" to "3.0" may be accomplished by registering two of them. Should an Upcaster fail,
+// an HTTP 400 is recorded for the Event. Upcast panics if the constraint is invalid.
+func (s *TypeDispatchSubscriber) Upcast(eventType string, up Upcaster) *TypeDispatchSubscriber {
+	eventType, version := splitDataVersion(eventType)
+
+	registered := upcaster{convert: up}
+	if version != "" {
+		parsed, err := parseVersionConstraint(version)
+		if err != nil {
+			panic(err)
+		}
+		registered.version = parsed
+	}
+
+	eventType = s.NormalizeEventType(eventType)
+	s.upcasters[eventType] = append(s.upcasters[eventType], registered)
+	return s
+}
+
+// upcast applies each Upcaster registered for an Event's Type, until none remain which
+// should be applied to it.
+func (s TypeDispatchSubscriber) upcast(e Event) (Event, error) {
+	registered := s.upcasters[s.NormalizeEventType(e.EventType)]
+	applied := make([]bool, len(registered))
+
+	for converted := true; converted; {
+		converted = false
+		for i, up := range registered {
+			if applied[i] || (up.version != nil && !up.version.allows(e.DataVersion)) {
+				continue
+			}
+
+			var err error
+			if e, err = up.convert(e); err != nil {
+				return e, err
+			}
+			applied[i], converted = true, true
+			break
+		}
+	}
+	return e, nil
+}
+
+// UpcastData creates an Upcaster from a function converting the data of one DataVersion into
+// the data of another. The function must have the signature:
+//
+//	func(*Old) (*New, error)
+//
+// where Old is the type the data is read into, and New is the type that is written in its
+// place. Either may also be used by value instead of by pointer. The upcast Event carries
+// toVersion as its DataVersion. Should the data not fit Old, a `DataDecodeError` is reported.
+// UpcastData panics if the function does not have a supported signature.
+func UpcastData(toVersion string, fn interface{}) Upcaster {
+	t := reflect.TypeOf(fn)
+	if fn == nil || t.Kind() != reflect.Func || reflect.ValueOf(fn).IsNil() {
+		panic(fmt.Sprintf("eventgrid: upcaster must be a non-nil function, not %v", t))
+	}
+
+	if t.IsVariadic() || t.NumIn() != 1 || t.NumOut() != 2 || t.Out(1) != errorInterfaceType {
+		panic(fmt.Sprintf("eventgrid: upcaster %v must have the signature func(*Old) (*New, error)", t))
+	}
+
+	from := t.In(0)
+	target, byValue := from, false
+	if from.Kind() != reflect.Ptr {
+		target, byValue = reflect.PtrTo(from), true
+	}
+
+	callable := reflect.ValueOf(fn)
+	return func(e Event) (Event, error) {
+		payload := reflect.New(target.Elem())
+		if err := e.UnmarshalData(payload.Interface()); err != nil {
+			return e, DataDecodeError{
+				EventID:   e.ID,
+				EventType: e.EventType,
+				Target:    from,
+				Err:       err,
+			}
+		}
+
+		if byValue {
+			payload = payload.Elem()
+		}
+
+		results := callable.Call([]reflect.Value{payload})
+		if failure, _ := results[1].Interface().(error); failure != nil {
+			return e, failure
+		}
+
+		data, err := json.Marshal(results[0].Interface())
+		if err != nil {
+			return e, err
+		}
+
+		e.Data = data
+		e.DataVersion = toVersion
+		return e, nil
+	}
+}
@@ -0,0 +1,80 @@
+package eventgrid
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// DefaultWebHookOrigin is the value of the `WebHook-Request-Origin` header sent by
+// Event Grid when it validates an endpoint for a CloudEvents subscription.
+const DefaultWebHookOrigin = "eventgrid.azure.net"
+
+// WebHookOriginWildcard is a special-case value that can be added to the allowed origins
+// of a `WebHookValidation` to accept requests from any origin.
+const WebHookOriginWildcard = "*"
+
+// WebHookValidation answers the abuse-protection handshake described by the CloudEvents
+// HTTP Web Hook specification. Event Grid uses this handshake, instead of a
+// "SubscriptionValidation" event, to validate endpoints for CloudEvents subscriptions.
+//
+// External documentation on the handshake can be found here:
+// https://github.com/cloudevents/spec/blob/v1.0/http-webhook.md#4-abuse-protection
+type WebHookValidation struct {
+	// AllowedOrigins are the values of `WebHook-Request-Origin` that will be granted
+	// permission to deliver events. When empty, only `DefaultWebHookOrigin` is allowed.
+	AllowedOrigins []string
+
+	// AllowedRate is the number of requests per minute that will be granted to an
+	// allowed origin. When zero or less, no limit is imposed.
+	AllowedRate int
+}
+
+// Handler is a `buffalo.Handler` meant to be bound to HTTP OPTIONS requests for a
+// route that receives events. It grants permission to deliver events to any origin in
+// the allow-list, and responds with an HTTP 403 Status Code to all others.
+func (v WebHookValidation) Handler(c buffalo.Context) error {
+	origin := strings.TrimSpace(c.Request().Header.Get("WebHook-Request-Origin"))
+	if origin == "" {
+		return c.Error(http.StatusBadRequest, errors.New("missing WebHook-Request-Origin header"))
+	}
+
+	if !v.Allows(origin) {
+		return c.Error(http.StatusForbidden, fmt.Errorf("origin %q is not allowed to deliver events", origin))
+	}
+
+	if logger := c.Logger(); logger != nil {
+		logger.Info("received web hook validation request from: ", origin)
+	}
+
+	header := c.Response().Header()
+	header.Set("WebHook-Allowed-Origin", origin)
+	if v.AllowedRate > 0 {
+		header.Set("WebHook-Allowed-Rate", strconv.Itoa(v.AllowedRate))
+	} else {
+		header.Set("WebHook-Allowed-Rate", "*")
+	}
+	header.Set("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost}, ", "))
+
+	c.Response().WriteHeader(http.StatusOK)
+	return nil
+}
+
+// Allows determines whether or not an origin is in the allow-list.
+func (v WebHookValidation) Allows(origin string) bool {
+	allowed := v.AllowedOrigins
+	if len(allowed) == 0 {
+		allowed = []string{DefaultWebHookOrigin}
+	}
+
+	for _, candidate := range allowed {
+		if candidate == WebHookOriginWildcard || strings.EqualFold(candidate, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package eventgrid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/buffalo-azure/sdk/eventgrid"
+	"github.com/gobuffalo/buffalo"
+)
+
+func TestWebHookValidation_Handler(t *testing.T) {
+	testCases := []struct {
+		name       string
+		subject    eventgrid.WebHookValidation
+		origin     string
+		wantStatus int
+		wantRate   string
+	}{
+		{"default", eventgrid.WebHookValidation{}, eventgrid.DefaultWebHookOrigin, http.StatusOK, "*"},
+		{"default-rejected", eventgrid.WebHookValidation{}, "contoso.com", http.StatusForbidden, ""},
+		{"missing", eventgrid.WebHookValidation{}, "", http.StatusBadRequest, ""},
+		{"allow-list", eventgrid.WebHookValidation{AllowedOrigins: []string{"contoso.com"}, AllowedRate: 120}, "Contoso.com", http.StatusOK, "120"},
+		{"wildcard", eventgrid.WebHookValidation{AllowedOrigins: []string{eventgrid.WebHookOriginWildcard}}, "contoso.com", http.StatusOK, "*"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, "localhost", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.origin != "" {
+				req.Header.Add("WebHook-Request-Origin", tc.origin)
+			}
+
+			ctx := NewMockContext(req)
+			err = tc.subject.Handler(ctx)
+			got := ctx.Status()
+			if httpErr, ok := err.(buffalo.HTTPError); ok {
+				got = httpErr.Status
+			}
+
+			if got != tc.wantStatus {
+				t.Errorf("got status: %d want: %d", got, tc.wantStatus)
+			}
+
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			if got := ctx.Header().Get("WebHook-Allowed-Origin"); got != tc.origin {
+				t.Errorf("got origin: %q want: %q", got, tc.origin)
+			}
+
+			if got := ctx.Header().Get("WebHook-Allowed-Rate"); got != tc.wantRate {
+				t.Errorf("got rate: %q want: %q", got, tc.wantRate)
+			}
+		})
+	}
+}
+
+func TestRegisterSubscriber_WebHookValidation(t *testing.T) {
+	app := buffalo.New(buffalo.Options{Env: "test"})
+	eventgrid.RegisterSubscriber(app, "/events", eventgrid.BaseSubscriber{}, eventgrid.WithAllowedOrigins("contoso.com"), eventgrid.WithAllowedRate(10))
+
+	req := httptest.NewRequest(http.MethodOptions, "/events/", nil)
+	req.Header.Add("WebHook-Request-Origin", "contoso.com")
+	resp := httptest.NewRecorder()
+
+	app.ServeHTTP(resp, req)
+
+	if got, want := resp.Code, http.StatusOK; got != want {
+		t.Errorf("got status: %d want: %d", got, want)
+	}
+
+	if got, want := resp.Header().Get("WebHook-Allowed-Rate"), "10"; got != want {
+		t.Errorf("got rate: %q want: %q", got, want)
+	}
+}
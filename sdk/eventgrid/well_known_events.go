@@ -0,0 +1,28 @@
+package eventgrid
+
+import (
+	azeg "github.com/Azure/azure-sdk-for-go/services/eventgrid/2018-01-01/eventgrid"
+)
+
+// wellKnownEvents associates the Event Types published by Azure services with the types that
+// describe their data. They are registered in each TypeRegistry created by `NewTypeRegistry`.
+var wellKnownEvents = map[string]interface{}{
+	"Microsoft.ContainerRegistry.ImagePushed":                              azeg.ContainerRegistryImagePushedEventData{},
+	"Microsoft.ContainerRegistry.ImageDeleted":                             azeg.ContainerRegistryImageDeletedEventData{},
+	"Microsoft.EventGrid.SubscriptionValidationEvent":                      azeg.SubscriptionValidationEventData{},
+	"Microsoft.EventGrid.SubscriptionDeletedEvent":                         azeg.SubscriptionDeletedEventData{},
+	"Microsoft.EventHub.CaptureFileCreated":                                azeg.EventHubCaptureFileCreatedEventData{},
+	"Microsoft.Devices.DeviceCreated":                                      azeg.IotHubDeviceCreatedEventData{},
+	"Microsoft.Devices.DeviceDeleted":                                      azeg.IotHubDeviceDeletedEventData{},
+	"Microsoft.Media.JobStateChanged":                                      azeg.MediaJobStateChangeEventData{},
+	"Microsoft.Resources.ResourceDeleteCancel":                             azeg.ResourceDeleteCancelData{},
+	"Microsoft.Resources.ResourceDeleteFailure":                            azeg.ResourceDeleteFailureData{},
+	"Microsoft.Resources.ResourceDeleteSuccess":                            azeg.ResourceDeleteSuccessData{},
+	"Microsoft.Resources.ResourceWriteCancel":                              azeg.ResourceWriteCancelData{},
+	"Microsoft.Resources.ResourceWriteFailure":                             azeg.ResourceWriteFailureData{},
+	"Microsoft.Resources.ResourceWriteSuccess":                             azeg.ResourceWriteSuccessData{},
+	"Microsoft.ServiceBus.ActiveMessagesAvailableWithNoListeners":          azeg.ServiceBusActiveMessagesAvailableWithNoListenersEventData{},
+	"Microsoft.ServiceBus.DeadletterMessagesAvailableWithNoListenersEvent": azeg.ServiceBusDeadletterMessagesAvailableWithNoListenersEventData{},
+	"Microsoft.Storage.BlobCreated":                                        azeg.StorageBlobCreatedEventData{},
+	"Microsoft.Storage.BlobDeleted":                                        azeg.StorageBlobDeletedEventData{},
+}
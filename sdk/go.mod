@@ -1,10 +1,13 @@
 module github.com/Azure/buffalo-azure/sdk
 
 require (
+	github.com/Azure/azure-sdk-for-go v18.0.0+incompatible
+	github.com/Azure/go-autorest v10.12.0+incompatible // indirect
 	github.com/cockroachdb/apd v1.1.0 // indirect
 	github.com/cockroachdb/cockroach-go v0.0.0-20181001143604-e0a95dfd547c // indirect
 	github.com/gobuffalo/buffalo v0.13.0
 	github.com/gobuffalo/fizz v1.0.12 // indirect
+	github.com/gobuffalo/pop v4.8.4+incompatible
 	github.com/gobuffalo/uuid v2.0.4+incompatible
 	github.com/gofrs/uuid v3.1.0+incompatible // indirect
 	github.com/jackc/fake v0.0.0-20150926172116-812a484cc733 // indirect
@@ -13,4 +16,9 @@ require (
 	github.com/pkg/errors v0.8.0
 	github.com/satori/go.uuid v1.2.0 // indirect
 	github.com/shopspring/decimal v0.0.0-20180709203117-cd690d0c9e24 // indirect
+	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
+	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
+	github.com/xeipuuv/gojsonschema v1.1.0
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/sys v0.10.0 // indirect
 )